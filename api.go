@@ -0,0 +1,21 @@
+package mikrus
+
+// API represents operations of the Mikrus API on a single server,
+// identified by the API key and server ID. *Client implements API.
+//
+// Code calling the Mikrus API can accept an API, so it can be tested
+// with the in-memory fake from the mikrusfake package.
+type API interface {
+	Info() (Server, error)
+	Servers() (Servers, error)
+	Logs() (Logs, error)
+	Stats() (Stats, error)
+	Ports() ([]int, error)
+	AssignDomain(port int, domain string) (TaskResponse, error)
+	Boost() (TaskResponse, error)
+	Restart() (TaskResponse, error)
+	Exec(command string) (string, error)
+	UploadSSHKey(pubKey string) (TaskResponse, error)
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,297 @@
+// Package apply converges Mikrus servers to a desired state described
+// in a YAML file: domains assigned to ports, uploaded SSH keys and
+// a boost schedule.
+//
+// The API does not report assigned domains or uploaded keys, so changes
+// made by apply are recorded in a local state file, like in Terraform.
+// Domains and keys not in the desired state are left as they are,
+// because the API cannot remove them.
+package apply
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"go.yaml.in/yaml/v3"
+)
+
+// Config describes the desired state of servers.
+type Config struct {
+	Servers []ServerConfig `yaml:"servers"`
+}
+
+// ServerConfig describes the desired state of a server.
+type ServerConfig struct {
+	ID string `yaml:"id"`
+
+	// Domains maps ports to domains assigned to them.
+	Domains map[int]string `yaml:"domains"`
+
+	// SSHKeys lists OpenSSH public keys, or paths of public key
+	// files, uploaded to the server.
+	SSHKeys []string `yaml:"sshKeys"`
+
+	// Boost schedules boosting the server.
+	Boost *Boost `yaml:"boost"`
+}
+
+// Boost describes how often the server is boosted.
+type Boost struct {
+	Every time.Duration `yaml:"every"`
+}
+
+// LoadConfig reads the desired state from a YAML file. SSH key
+// files are read and all keys are validated.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing desired state %q: %w", path, err)
+	}
+	ids := map[string]bool{}
+	for i := range cfg.Servers {
+		s := &cfg.Servers[i]
+		if s.ID == "" {
+			return Config{}, errors.New("server ID is required")
+		}
+		if ids[s.ID] {
+			return Config{}, fmt.Errorf("duplicate server %q", s.ID)
+		}
+		ids[s.ID] = true
+		if s.Boost != nil && s.Boost.Every <= 0 {
+			return Config{}, fmt.Errorf("server %q: boost interval must be positive", s.ID)
+		}
+		for j, key := range s.SSHKeys {
+			if key, err = readKey(key, filepath.Dir(path)); err != nil {
+				return Config{}, fmt.Errorf("server %q: %w", s.ID, err)
+			}
+			s.SSHKeys[j] = key
+		}
+	}
+	return cfg, nil
+}
+
+// readKey returns the key, read from the file if the key is a path.
+// Relative paths are relative to dir.
+func readKey(key, dir string) (string, error) {
+	if !strings.ContainsAny(key, " \t") {
+		path := key
+		if strings.HasPrefix(path, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, path[2:])
+		} else if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		key = string(data)
+	}
+	parsed, err := mikrus.ParseSSHPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+// Action is the kind of a change.
+type Action string
+
+const (
+	AssignDomain Action = "assign-domain"
+	UploadSSHKey Action = "upload-ssh-key"
+	BoostServer  Action = "boost"
+)
+
+// Change represents a single change of a server.
+type Change struct {
+	ServerID  string `json:"server_id"`
+	Action    Action `json:"action"`
+	Port      int    `json:"port,omitempty"`
+	Domain    string `json:"domain,omitempty"`
+	OldDomain string `json:"old_domain,omitempty"`
+	SSHKey    string `json:"ssh_key,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// String implements stringer interface.
+func (c Change) String() string {
+	switch c.Action {
+	case AssignDomain:
+		if c.OldDomain != "" {
+			return fmt.Sprintf("~ domain on port %d: %s -> %s", c.Port, c.OldDomain, c.Domain)
+		}
+		return fmt.Sprintf("+ domain on port %d: %s", c.Port, c.Domain)
+	case UploadSSHKey:
+		return "+ ssh key " + shortKey(c.SSHKey)
+	default:
+		return "+ boost (" + c.Reason + ")"
+	}
+}
+
+// shortKey abbreviates the key data for display.
+func shortKey(key string) string {
+	fields := strings.Fields(key)
+	if len(fields) < 2 || len(fields[1]) <= 16 {
+		return key
+	}
+	fields[1] = "..." + fields[1][len(fields[1])-12:]
+	return strings.Join(fields, " ")
+}
+
+// Plan lists changes needed to converge servers to the desired state.
+type Plan struct {
+	Changes []Change `json:"changes"`
+}
+
+// String returns the plan in a diff-like format, grouped by server.
+func (p Plan) String() string {
+	if len(p.Changes) == 0 {
+		return "No changes. Servers match the desired state.\n"
+	}
+	var b strings.Builder
+	server := ""
+	for _, c := range p.Changes {
+		if c.ServerID != server {
+			if server != "" {
+				b.WriteString("\n")
+			}
+			server = c.ServerID
+			fmt.Fprintf(&b, "server %s:\n", server)
+		}
+		fmt.Fprintf(&b, "  %s\n", c)
+	}
+	fmt.Fprintf(&b, "\nPlan: %d to change.\n", len(p.Changes))
+	return b.String()
+}
+
+// Source provides the current state of a server.
+// *mikrus.Client implements Source.
+type Source interface {
+	Ports() ([]int, error)
+	Logs() (mikrus.Logs, error)
+}
+
+// Compute returns the plan converging the server to the desired state,
+// given the recorded state and the current state from the source.
+func Compute(cfg ServerConfig, st ServerState, src Source, now time.Time) (Plan, error) {
+	var plan Plan
+	if len(cfg.Domains) > 0 {
+		ports, err := src.Ports()
+		if err != nil {
+			return Plan{}, fmt.Errorf("server %q: getting ports: %w", cfg.ID, err)
+		}
+		for _, port := range sortedPorts(cfg.Domains) {
+			if !slices.Contains(ports, port) {
+				return Plan{}, fmt.Errorf("server %q: port %d is not assigned to the server, want one of %v", cfg.ID, port, ports)
+			}
+			domain := cfg.Domains[port]
+			if current := st.Domains[port]; current != domain {
+				plan.Changes = append(plan.Changes, Change{
+					ServerID:  cfg.ID,
+					Action:    AssignDomain,
+					Port:      port,
+					Domain:    domain,
+					OldDomain: current,
+				})
+			}
+		}
+	}
+	for _, key := range cfg.SSHKeys {
+		if !slices.Contains(st.SSHKeys, key) {
+			plan.Changes = append(plan.Changes, Change{ServerID: cfg.ID, Action: UploadSSHKey, SSHKey: key})
+		}
+	}
+	if cfg.Boost != nil {
+		logs, err := src.Logs()
+		if err != nil {
+			return Plan{}, fmt.Errorf("server %q: getting logs: %w", cfg.ID, err)
+		}
+		last := lastBoost(st.LastBoost, logs)
+		if next := last.Add(cfg.Boost.Every); !now.Before(next) {
+			reason := "never boosted"
+			if !last.IsZero() {
+				reason = fmt.Sprintf("last boost %s ago, every %s", now.Sub(last).Round(time.Minute), cfg.Boost.Every)
+			}
+			plan.Changes = append(plan.Changes, Change{ServerID: cfg.ID, Action: BoostServer, Reason: reason})
+		}
+	}
+	return plan, nil
+}
+
+// lastBoost returns the time of the last boost recorded in the state
+// or in the logs.
+func lastBoost(recorded time.Time, logs mikrus.Logs) time.Time {
+	last := recorded
+	for _, l := range logs {
+		if l.Task != "amfetamina" {
+			continue
+		}
+		if created, err := l.CreatedAt(); err == nil && created.After(last) {
+			last = created
+		}
+	}
+	return last
+}
+
+func sortedPorts(domains map[int]string) []int {
+	ports := make([]int, 0, len(domains))
+	for port := range domains {
+		ports = append(ports, port)
+	}
+	slices.Sort(ports)
+	return ports
+}
+
+// Executor makes changes on a server.
+// *mikrus.Client implements Executor.
+type Executor interface {
+	AssignDomain(port int, domain string) (mikrus.TaskResponse, error)
+	UploadSSHKey(pubKey string) (mikrus.TaskResponse, error)
+	Boost() (mikrus.TaskResponse, error)
+}
+
+// Apply makes the changes of the plan for a single server and records
+// them in the state. It stops at the first failed change; changes made
+// before it stay recorded.
+func Apply(plan Plan, st *ServerState, e Executor, now time.Time) error {
+	for _, c := range plan.Changes {
+		var err error
+		switch c.Action {
+		case AssignDomain:
+			if _, err = e.AssignDomain(c.Port, c.Domain); err == nil {
+				if st.Domains == nil {
+					st.Domains = map[int]string{}
+				}
+				st.Domains[c.Port] = c.Domain
+			}
+		case UploadSSHKey:
+			if _, err = e.UploadSSHKey(c.SSHKey); err == nil {
+				st.SSHKeys = append(st.SSHKeys, c.SSHKey)
+			}
+		case BoostServer:
+			if _, err = e.Boost(); err == nil {
+				st.LastBoost = now.UTC()
+			}
+		default:
+			err = fmt.Errorf("unknown action %q", c.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("server %q: %s: %w", c.ServerID, c, err)
+		}
+	}
+	return nil
+}
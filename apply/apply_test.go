@@ -0,0 +1,229 @@
+package apply_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/apply"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+const (
+	laptopKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f jakub@laptop"
+	deployKey = "ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAAAQQABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4fICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj9A deploy@ci"
+)
+
+var now = time.Date(2024, time.June, 6, 12, 0, 0, 0, time.UTC)
+
+func loadConfig(t *testing.T) apply.ServerConfig {
+	t.Helper()
+	cfg, err := apply.LoadConfig("testdata/mikrus.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Servers[0]
+}
+
+func TestLoadConfig_ReadsKeyFiles(t *testing.T) {
+	t.Parallel()
+	got := loadConfig(t)
+	want := apply.ServerConfig{
+		ID:      "j230",
+		Domains: map[int]string{20230: "app.example.com", 30230: "api.example.com"},
+		SSHKeys: []string{laptopKey, deployKey},
+		Boost:   &apply.Boost{Every: 24 * time.Hour},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestCompute_PlansAllChangesForEmptyState(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+	c := ts.Client("j230")
+
+	got, err := apply.Compute(loadConfig(t), apply.ServerState{}, &c, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := apply.Plan{Changes: []apply.Change{
+		{ServerID: "j230", Action: apply.AssignDomain, Port: 20230, Domain: "app.example.com"},
+		{ServerID: "j230", Action: apply.AssignDomain, Port: 30230, Domain: "api.example.com"},
+		{ServerID: "j230", Action: apply.UploadSSHKey, SSHKey: laptopKey},
+		{ServerID: "j230", Action: apply.UploadSSHKey, SSHKey: deployKey},
+		{ServerID: "j230", Action: apply.BoostServer, Reason: "never boosted"},
+	}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestCompute_PlansOnlyDifferences(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+	c := ts.Client("j230")
+
+	st := apply.ServerState{
+		Domains:   map[int]string{20230: "app.example.com", 30230: "old.example.com"},
+		SSHKeys:   []string{laptopKey, deployKey},
+		LastBoost: now.Add(-2 * time.Hour),
+	}
+	got, err := apply.Compute(loadConfig(t), st, &c, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := apply.Plan{Changes: []apply.Change{
+		{ServerID: "j230", Action: apply.AssignDomain, Port: 30230, Domain: "api.example.com", OldDomain: "old.example.com"},
+	}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+type fakeSource struct {
+	logs mikrus.Logs
+}
+
+func (f fakeSource) Ports() ([]int, error)       { return []int{10230}, nil }
+func (f fakeSource) Logs() (mikrus.Logs, error) { return f.logs, nil }
+
+func TestCompute_SchedulesBoostFromLastBoostInLogs(t *testing.T) {
+	t.Parallel()
+	cfg := apply.ServerConfig{ID: "j230", Boost: &apply.Boost{Every: 24 * time.Hour}}
+	src := fakeSource{logs: mikrus.Logs{{ID: "1", Task: "amfetamina", WhenCreated: "2024-06-05 13:00:00"}}}
+
+	got, err := apply.Compute(cfg, apply.ServerState{}, src, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := apply.Plan{Changes: []apply.Change{
+		{ServerID: "j230", Action: apply.BoostServer, Reason: "last boost 25h0m0s ago, every 24h0m0s"},
+	}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	got, err = apply.Compute(cfg, apply.ServerState{}, src, now.Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Changes) != 0 {
+		t.Errorf("want no boost within interval, got %v", got.Changes)
+	}
+}
+
+func TestCompute_ErrorsForPortNotAssignedToServer(t *testing.T) {
+	t.Parallel()
+	cfg := apply.ServerConfig{ID: "j230", Domains: map[int]string{443: "app.example.com"}}
+	if _, err := apply.Compute(cfg, apply.ServerState{}, fakeSource{}, now); err == nil {
+		t.Fatal("want error, got nil")
+	}
+}
+
+func TestApply_ConvergesServerAndRecordsState(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+	c := ts.Client("j230")
+	cfg := loadConfig(t)
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := apply.LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := apply.Compute(cfg, *state.Server("j230"), &c, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := apply.Apply(plan, state.Server("j230"), &c, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	wantDomains := map[int]string{20230: "app.example.com", 30230: "api.example.com"}
+	if got := ts.Handler.Domains("j230"); !cmp.Equal(wantDomains, got) {
+		t.Error(cmp.Diff(wantDomains, got))
+	}
+	if got := ts.Handler.SSHKeys("j230"); !cmp.Equal([]string{laptopKey, deployKey}, got) {
+		t.Errorf("want uploaded keys, got %v", got)
+	}
+	if got := ts.Handler.Calls("amfetamina"); got != 1 {
+		t.Errorf("want 1 boost, got %d", got)
+	}
+
+	state, err = apply.LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err = apply.Compute(cfg, *state.Server("j230"), &c, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Errorf("want no changes after apply, got:\n%s", plan)
+	}
+}
+
+type failingExecutor struct {
+	apply.Executor
+}
+
+func (failingExecutor) AssignDomain(int, string) (mikrus.TaskResponse, error) {
+	return mikrus.TaskResponse{}, nil
+}
+
+func (failingExecutor) UploadSSHKey(string) (mikrus.TaskResponse, error) {
+	return mikrus.TaskResponse{}, errors.New("boom")
+}
+
+func TestApply_StopsAtFirstFailureKeepingAppliedChanges(t *testing.T) {
+	t.Parallel()
+	plan := apply.Plan{Changes: []apply.Change{
+		{ServerID: "j230", Action: apply.AssignDomain, Port: 20230, Domain: "app.example.com"},
+		{ServerID: "j230", Action: apply.UploadSSHKey, SSHKey: laptopKey},
+		{ServerID: "j230", Action: apply.BoostServer},
+	}}
+	var st apply.ServerState
+	if err := apply.Apply(plan, &st, failingExecutor{}, now); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	want := apply.ServerState{Domains: map[int]string{20230: "app.example.com"}}
+	if !cmp.Equal(want, st) {
+		t.Error(cmp.Diff(want, st))
+	}
+}
+
+func TestPlan_StringShowsDiff(t *testing.T) {
+	t.Parallel()
+	plan := apply.Plan{Changes: []apply.Change{
+		{ServerID: "a101", Action: apply.BoostServer, Reason: "never boosted"},
+		{ServerID: "j230", Action: apply.AssignDomain, Port: 30230, Domain: "api.example.com", OldDomain: "old.example.com"},
+		{ServerID: "j230", Action: apply.UploadSSHKey, SSHKey: laptopKey},
+	}}
+	want := `server a101:
+  + boost (never boosted)
+
+server j230:
+  ~ domain on port 30230: old.example.com -> api.example.com
+  + ssh key ssh-ed25519 ...FxgZGhscHR4f jakub@laptop
+
+Plan: 3 to change.
+`
+	if got := plan.String(); got != want {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got := (apply.Plan{}).String(); !strings.HasPrefix(got, "No changes.") {
+		t.Errorf("want no changes message, got %q", got)
+	}
+}
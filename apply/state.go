@@ -0,0 +1,70 @@
+package apply
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State records changes applied to servers.
+type State struct {
+	Servers map[string]*ServerState `json:"servers"`
+}
+
+// ServerState records changes applied to a server.
+type ServerState struct {
+	Domains   map[int]string `json:"domains,omitempty"`
+	SSHKeys   []string       `json:"ssh_keys,omitempty"`
+	LastBoost time.Time      `json:"last_boost,omitzero"`
+}
+
+// Server returns the recorded state of the server, adding an empty
+// one when the server has none.
+func (s *State) Server(id string) *ServerState {
+	if s.Servers == nil {
+		s.Servers = map[string]*ServerState{}
+	}
+	st, ok := s.Servers[id]
+	if !ok {
+		st = &ServerState{}
+		s.Servers[id] = st
+	}
+	return st
+}
+
+// LoadState reads the state from the file. It returns an empty state
+// if the file does not exist.
+func LoadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Save writes the state to the file, creating its directory
+// when needed.
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
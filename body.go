@@ -0,0 +1,64 @@
+package mikrus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxBodySize is the maximum size of API responses in bytes
+// used when Client.MaxBodySize is zero.
+const DefaultMaxBodySize = 4 << 20
+
+// ErrResponseTooLarge is returned when a response of the API is larger
+// than the maximum body size of the client.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// snippetSize is the number of bytes of a response body included
+// in errors.
+const snippetSize = 512
+
+// bodyReader reads a response body up to a limit. It counts bytes read
+// and keeps the beginning of the body for error messages, so bodies
+// can be decoded as they are read.
+type bodyReader struct {
+	r       io.Reader
+	limit   int64
+	n       int64
+	snippet []byte
+}
+
+func newBodyReader(r io.Reader, limit int64) *bodyReader {
+	return &bodyReader{r: io.LimitReader(r, limit+1), limit: limit}
+}
+
+// Read implements io.Reader. It returns an error wrapping
+// ErrResponseTooLarge when the body exceeds the limit.
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	if room := snippetSize + 1 - len(b.snippet); room > 0 {
+		b.snippet = append(b.snippet, p[:min(n, room)]...)
+	}
+	if b.n > b.limit {
+		return n, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	return n, err
+}
+
+// maxBodySize returns the maximum size of responses of the client.
+func (c *Client) maxBodySize() int64 {
+	if c.MaxBodySize > 0 {
+		return c.MaxBodySize
+	}
+	return DefaultMaxBodySize
+}
+
+// snippet returns the beginning of the body for error messages, with
+// secrets redacted.
+func (c *Client) snippet(body []byte) string {
+	if len(body) > snippetSize {
+		return c.redact(string(body[:snippetSize])) + "..."
+	}
+	return c.redact(string(body))
+}
@@ -0,0 +1,92 @@
+package mikrus_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/qba73/mikrus"
+)
+
+// newSizedServer returns a server responding to every call with the
+// status and body.
+func newSizedServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func newSizedClient(ts *httptest.Server) mikrus.Client {
+	c := mikrus.New("dummyKey", "dummySrv")
+	c.URL = ts.URL
+	return c
+}
+
+func TestClient_ErrorsForResponsesLargerThanMaxBodySize(t *testing.T) {
+	t.Parallel()
+	output := strings.Repeat("x", 2048)
+	ts := newSizedServer(http.StatusOK, `{"output":"`+output+`","server_id":"`+output+`"}`)
+	defer ts.Close()
+
+	c := newSizedClient(ts)
+	c.MaxBodySize = 1024
+	if _, err := c.Exec("cat /var/log/syslog"); !errors.Is(err, mikrus.ErrResponseTooLarge) {
+		t.Errorf("exec: want ErrResponseTooLarge, got %v", err)
+	}
+	if _, err := c.Info(); !errors.Is(err, mikrus.ErrResponseTooLarge) {
+		t.Errorf("info: want ErrResponseTooLarge, got %v", err)
+	}
+
+	c.MaxBodySize = 8192
+	got, err := c.Exec("cat /var/log/syslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != output {
+		t.Errorf("want output of %d bytes, got %d", len(output), len(got))
+	}
+}
+
+func TestClient_UsesDefaultMaxBodySize(t *testing.T) {
+	t.Parallel()
+	ts := newSizedServer(http.StatusOK, `{"output":"`+strings.Repeat("x", mikrus.DefaultMaxBodySize)+`"}`)
+	defer ts.Close()
+
+	c := newSizedClient(ts)
+	if _, err := c.Exec("yes"); !errors.Is(err, mikrus.ErrResponseTooLarge) {
+		t.Errorf("want ErrResponseTooLarge, got %v", err)
+	}
+}
+
+func TestClient_CapsResponseBodiesInErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		truncated bool
+	}{
+		{name: "error status", status: http.StatusBadGateway, body: strings.Repeat("<html>", 10000), truncated: true},
+		{name: "invalid JSON", status: http.StatusOK, body: "{" + strings.Repeat(`"x":`, 10000)},
+	}
+	for _, tc := range tests {
+		ts := newSizedServer(tc.status, tc.body)
+		c := newSizedClient(ts)
+		_, err := c.Info()
+		ts.Close()
+		if err == nil {
+			t.Errorf("%s: want error, got nil", tc.name)
+			continue
+		}
+		if errors.Is(err, mikrus.ErrResponseTooLarge) {
+			t.Errorf("%s: want error other than ErrResponseTooLarge, got %v", tc.name, err)
+		}
+		if msg := err.Error(); len(msg) > 1024 || tc.truncated && !strings.Contains(msg, `..."`) {
+			t.Errorf("%s: want capped body in error, got %d bytes: %.100s", tc.name, len(msg), msg)
+		}
+	}
+}
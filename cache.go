@@ -0,0 +1,294 @@
+package mikrus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache stores responses of read-only API verbs. Entries are grouped
+// by scope, identifying the API URL, key and server, so all entries
+// of a server are invalidated together after a mutating call.
+//
+// Caches are safe for concurrent use.
+type Cache interface {
+	// Get returns the cached response of the verb, if present and
+	// not expired.
+	Get(scope, verb string) ([]byte, bool)
+
+	// Set caches the response of the verb for the TTL.
+	Set(scope, verb string, body []byte, ttl time.Duration)
+
+	// Invalidate removes all responses cached in the scope.
+	Invalidate(scope string)
+}
+
+// DefaultCacheTTLs are TTLs of responses of read-only verbs used when
+// Client.CacheTTLs is nil. Stats are not cached, as they change
+// all the time.
+var DefaultCacheTTLs = map[string]time.Duration{
+	"info":    5 * time.Minute,
+	"serwery": 5 * time.Minute,
+	"porty":   5 * time.Minute,
+	"logs":    30 * time.Second,
+}
+
+// readOnlyVerbs lists verbs that do not change servers. Calls to all
+// other verbs invalidate cached responses of the server.
+var readOnlyVerbs = map[string]bool{
+	"info":    true,
+	"serwery": true,
+	"logs":    true,
+	"stats":   true,
+	"porty":   true,
+	"cloud":   true,
+	"db":      true,
+}
+
+// cacheTTL returns the TTL of the response of the verb, or zero when
+// the response is not cached.
+func (c *Client) cacheTTL(verb string) time.Duration {
+	if c.Cache == nil {
+		return 0
+	}
+	ttls := c.CacheTTLs
+	if ttls == nil {
+		ttls = DefaultCacheTTLs
+	}
+	return ttls[verb]
+}
+
+// cacheScope returns the scope of cached responses of the client.
+// The API key is hashed, so it is not stored in cache keys.
+func (c *Client) cacheScope() string {
+	sum := sha256.Sum256([]byte(c.URL + "\x00" + c.apiKey + "\x00" + c.serverID))
+	return hex.EncodeToString(sum[:12])
+}
+
+// scopeGenerations counts invalidations of cache scopes, so responses
+// read before an invalidation are not cached after it.
+type scopeGenerations struct {
+	mu sync.Mutex
+	n  map[string]uint64
+}
+
+// generations tracks invalidations of all clients in the process.
+var generations = &scopeGenerations{}
+
+// get returns the current generation of the scope.
+func (g *scopeGenerations) get(scope string) uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.n[scope]
+}
+
+// invalidate starts a new generation of the scope and calls fn.
+func (g *scopeGenerations) invalidate(scope string, fn func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.n == nil {
+		g.n = map[string]uint64{}
+	}
+	g.n[scope]++
+	fn()
+}
+
+// ifCurrent calls fn if the scope is still in the generation gen.
+func (g *scopeGenerations) ifCurrent(scope string, gen uint64, fn func()) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.n[scope] == gen {
+		fn()
+	}
+}
+
+// CacheStats counts cache lookups and invalidations.
+type CacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Invalidations int64 `json:"invalidations"`
+}
+
+// HitRatio returns the ratio of lookups that were hits.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cacheCounters struct {
+	hits, misses, invalidations atomic.Int64
+}
+
+func (c *cacheCounters) lookup(hit bool) {
+	if hit {
+		c.hits.Add(1)
+		return
+	}
+	c.misses.Add(1)
+}
+
+func (c *cacheCounters) stats() CacheStats {
+	return CacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+}
+
+type cacheEntry struct {
+	Body    json.RawMessage `json:"body"`
+	Expires time.Time       `json:"expires"`
+}
+
+// MemoryCache is a Cache keeping responses in memory.
+type MemoryCache struct {
+	counters cacheCounters
+
+	mu      sync.Mutex
+	entries map[string]map[string]cacheEntry
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: map[string]map[string]cacheEntry{}}
+}
+
+// Get implements Cache.
+func (m *MemoryCache) Get(scope, verb string) ([]byte, bool) {
+	m.mu.Lock()
+	e, ok := m.entries[scope][verb]
+	if ok && !time.Now().Before(e.Expires) {
+		delete(m.entries[scope], verb)
+		ok = false
+	}
+	m.mu.Unlock()
+	m.counters.lookup(ok)
+	return e.Body, ok
+}
+
+// Set implements Cache.
+func (m *MemoryCache) Set(scope, verb string, body []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[scope] == nil {
+		m.entries[scope] = map[string]cacheEntry{}
+	}
+	m.entries[scope][verb] = cacheEntry{Body: body, Expires: time.Now().Add(ttl)}
+}
+
+// Invalidate implements Cache.
+func (m *MemoryCache) Invalidate(scope string) {
+	m.mu.Lock()
+	delete(m.entries, scope)
+	m.mu.Unlock()
+	m.counters.invalidations.Add(1)
+}
+
+// Stats returns the cache statistics.
+func (m *MemoryCache) Stats() CacheStats {
+	return m.counters.stats()
+}
+
+// DiskCache is a Cache keeping responses in files, so they are shared
+// by processes, like subsequent runs of mikctl. Every scope has its own
+// directory with a file per verb. Errors reading and writing files
+// are treated as cache misses.
+type DiskCache struct {
+	dir      string
+	counters cacheCounters
+}
+
+// OpenDiskCache opens the cache in dir, creating the directory if needed.
+func OpenDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file of the cached verb. Scopes are hex encoded
+// and verbs are fixed API names, so they are safe to use in paths.
+func (d *DiskCache) path(scope, verb string) string {
+	return filepath.Join(d.dir, scope, verb+".json")
+}
+
+// Get implements Cache.
+func (d *DiskCache) Get(scope, verb string) ([]byte, bool) {
+	e, ok := d.read(d.path(scope, verb))
+	d.counters.lookup(ok)
+	return e.Body, ok
+}
+
+func (d *DiskCache) read(path string) (cacheEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || !time.Now().Before(e.Expires) {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+// Set implements Cache.
+func (d *DiskCache) Set(scope, verb string, body []byte, ttl time.Duration) {
+	if !json.Valid(body) {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{Body: body, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return
+	}
+	path := d.path(scope, verb)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), verb+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Invalidate implements Cache.
+func (d *DiskCache) Invalidate(scope string) {
+	os.RemoveAll(filepath.Join(d.dir, scope))
+	d.counters.invalidations.Add(1)
+}
+
+// Clear removes all cached responses.
+func (d *DiskCache) Clear() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(d.dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns statistics of lookups made with this DiskCache.
+func (d *DiskCache) Stats() CacheStats {
+	return d.counters.stats()
+}
@@ -0,0 +1,223 @@
+package mikrus_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+func TestClient_ServesReadOnlyVerbsFromCache(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	cache := mikrus.NewMemoryCache()
+	c := ts.Client("j230")
+	c.Cache = cache
+	for range 3 {
+		if _, err := c.Info(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Stats(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := ts.Handler.Calls("info"); got != 1 {
+		t.Errorf("want 1 info call, got %d", got)
+	}
+	if got := ts.Handler.Calls("stats"); got != 3 {
+		t.Errorf("want stats not cached, got %d calls", got)
+	}
+	want := mikrus.CacheStats{Hits: 2, Misses: 1}
+	if got := cache.Stats(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestClient_InvalidatesCacheAfterMutatingCalls(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	c.Cache = mikrus.NewMemoryCache()
+	before, err := c.Logs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	after, err := c.Logs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before)+1 || after[0].Task != "restart" {
+		t.Errorf("want fresh logs with the restart, got %v", after)
+	}
+	if got := ts.Handler.Calls("logs"); got != 2 {
+		t.Errorf("want 2 logs calls, got %d", got)
+	}
+}
+
+func TestClient_DoesNotCacheReadsStartedBeforeMutatingCalls(t *testing.T) {
+	t.Parallel()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var logsCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/logs":
+			if logsCalls.Add(1) == 1 {
+				close(started)
+				<-release
+				fmt.Fprint(w, `[{"id":"1","task":"before"}]`)
+				return
+			}
+			fmt.Fprint(w, `[{"id":"2","task":"after"}]`)
+		case "/restart":
+			fmt.Fprint(w, `{"msg":"OK"}`)
+		}
+	}))
+	defer ts.Close()
+
+	c := mikrus.New("testKey", "j230")
+	c.URL = ts.URL
+	c.HTTPClient = ts.Client()
+	c.Cache = mikrus.NewMemoryCache()
+	stale := make(chan error)
+	go func() {
+		_, err := c.Logs()
+		stale <- err
+	}()
+	<-started
+	if _, err := c.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	// Reads started after the restart don't wait for the stale read.
+	logs, err := c.Logs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs[0].Task != "after" {
+		t.Errorf("want logs read after the restart, got %v", logs)
+	}
+	close(release)
+	if err := <-stale; err != nil {
+		t.Fatal(err)
+	}
+	if logs, err = c.Logs(); err != nil {
+		t.Fatal(err)
+	}
+	if logs[0].Task != "after" {
+		t.Errorf("want cached logs read after the restart, got %v", logs)
+	}
+	if got := logsCalls.Load(); got != 2 {
+		t.Errorf("want 2 logs calls, got %d", got)
+	}
+}
+
+func TestClient_UsesCustomCacheTTLs(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	c.Cache = mikrus.NewMemoryCache()
+	c.CacheTTLs = map[string]time.Duration{"stats": time.Minute}
+	for range 2 {
+		if _, err := c.Stats(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Info(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := ts.Handler.Calls("stats"); got != 1 {
+		t.Errorf("want 1 stats call, got %d", got)
+	}
+	if got := ts.Handler.Calls("info"); got != 2 {
+		t.Errorf("want info not cached, got %d calls", got)
+	}
+}
+
+func TestClient_DoesNotShareCacheBetweenServers(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Servers = append(sc.Servers, mikrustest.ServerConfig{ID: "a101", APIKey: "otherKey"})
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	cache := mikrus.NewMemoryCache()
+	j230, a101 := ts.Client("j230"), ts.Client("a101")
+	j230.Cache, a101.Cache = cache, cache
+	for _, c := range []mikrus.Client{j230, a101} {
+		if _, err := c.Info(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	info, err := a101.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ServerID != "a101" {
+		t.Errorf("want info of a101, got %s", info.ServerID)
+	}
+	if got := ts.Handler.Calls("info"); got != 2 {
+		t.Errorf("want 2 info calls, got %d", got)
+	}
+}
+
+func TestDiskCache_SharesResponsesBetweenInstances(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	first, err := mikrus.OpenDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Set("scope", "info", []byte(`{"server_id":"j230"}`), time.Minute)
+	first.Set("scope", "logs", []byte(`[]`), -time.Second)
+
+	second, err := mikrus.OpenDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ok := second.Get("scope", "info")
+	if !ok || string(body) != `{"server_id":"j230"}` {
+		t.Errorf("want cached info, got %q, %v", body, ok)
+	}
+	if _, ok := second.Get("scope", "logs"); ok {
+		t.Error("want expired logs missing")
+	}
+	second.Invalidate("scope")
+	if _, ok := first.Get("scope", "info"); ok {
+		t.Error("want info invalidated")
+	}
+	want := mikrus.CacheStats{Hits: 1, Misses: 1, Invalidations: 1}
+	if got := second.Stats(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiskCache_ClearRemovesAllResponses(t *testing.T) {
+	t.Parallel()
+	cache, err := mikrus.OpenDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Set("a", "info", []byte(`{}`), time.Minute)
+	cache.Set("b", "serwery", []byte(`[]`), time.Minute)
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("a", "info"); ok {
+		t.Error("want cache cleared")
+	}
+}
@@ -0,0 +1,280 @@
+// Package cassette records Mikrus API interactions to golden files and
+// replays them, so the client can be tested without a network.
+//
+// Recorded files never contain the API key: the key form field is
+// replaced with a placeholder before a request is written to disk.
+// Other secrets, like database passwords in responses, are removed
+// with the Redact function of the Recorder. Replayers of such files
+// need the same Redact function to match requests.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// redacted replaces secrets in recorded requests.
+const redacted = "REDACTED"
+
+// secretFields are form fields never written to golden files.
+var secretFields = []string{"key"}
+
+// Interaction represents a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request represents a recorded HTTP request.
+type Request struct {
+	Method string     `json:"method"`
+	Path   string     `json:"path"`
+	Form   url.Values `json:"form,omitempty"`
+}
+
+// Response represents a recorded HTTP response.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper saving every request and response
+// passing through it to a golden file in a directory.
+type Recorder struct {
+	dir       string
+	transport http.RoundTripper
+
+	// Redact optionally removes secrets from recorded form values
+	// and response bodies, for example mikrus.Redactor.Redact.
+	Redact func(string) string
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewRecorder creates a recorder writing golden files to dir and
+// sending requests using the transport. If transport is nil,
+// http.DefaultTransport is used.
+func NewRecorder(dir string, transport http.RoundTripper) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	existing, err := goldenFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, transport: transport, n: len(existing)}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	recReq, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := http.Header{}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		header.Set("Content-Type", ct)
+	}
+	in := Interaction{
+		Request: recReq,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       string(body),
+		},
+	}
+	r.redact(&in)
+	if err := r.save(in); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// redact removes secrets from the interaction before it is saved.
+// Responses passed to the client are not changed.
+func (r *Recorder) redact(in *Interaction) {
+	if r.Redact == nil {
+		return
+	}
+	in.Request.redact(r.Redact)
+	in.Response.Body = r.Redact(in.Response.Body)
+}
+
+func (r *Recorder) save(in Interaction) error {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.n++
+	verb := strings.ReplaceAll(strings.Trim(in.Request.Path, "/"), "/", "-")
+	name := filepath.Join(r.dir, fmt.Sprintf("%04d-%s.json", r.n, verb))
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
+
+// Replayer is an http.RoundTripper serving responses from golden files
+// written by the Recorder. Requests are matched by method, path and
+// form fields other than the API key. Every recorded interaction is
+// served once, in the order it was recorded.
+type Replayer struct {
+	// Redact removes secrets from form values of requests before
+	// they are matched. It must be the function the interactions
+	// were recorded with.
+	Redact func(string) string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer creates a replayer serving golden files from dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := goldenFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded interactions in %q", dir)
+	}
+	r := &Replayer{used: make([]bool, len(files))}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var in Interaction
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, fmt.Errorf("decoding %q: %w", f, err)
+		}
+		r.interactions = append(r.interactions, in)
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper. It returns an error for
+// requests that were not recorded.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	got, err := newRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.Redact != nil {
+		got.redact(r.Redact)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.used[i] || !in.Request.matches(got) {
+			continue
+		}
+		r.used[i] = true
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("unexpected request %s %s %s", got.Method, got.Path, got.Form.Encode())
+}
+
+// Unused returns recorded interactions that have not been replayed.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, in := range r.interactions {
+		if !r.used[i] {
+			unused = append(unused, in)
+		}
+	}
+	return unused
+}
+
+// newRequest returns a redacted copy of the request for recording and
+// matching. The request body is restored, so it can be sent.
+func newRequest(req *http.Request) (Request, error) {
+	rec := Request{Method: req.Method, Path: req.URL.Path}
+	if req.Body == nil || req.Body == http.NoBody {
+		return rec, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return Request{}, fmt.Errorf("reading request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return Request{}, fmt.Errorf("parsing request form: %w", err)
+	}
+	for _, field := range secretFields {
+		if form.Has(field) {
+			form.Set(field, redacted)
+		}
+	}
+	rec.Form = form
+	return rec, nil
+}
+
+// redact replaces form values with their redacted versions.
+func (r Request) redact(fn func(string) string) {
+	for _, values := range r.Form {
+		for i, v := range values {
+			values[i] = fn(v)
+		}
+	}
+}
+
+// matches reports whether the recorded request matches the request.
+func (r Request) matches(other Request) bool {
+	if r.Method != other.Method || r.Path != other.Path {
+		return false
+	}
+	return r.Form.Encode() == other.Form.Encode()
+}
+
+// goldenFiles returns golden files in dir in the recording order.
+func goldenFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
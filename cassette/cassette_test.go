@@ -0,0 +1,190 @@
+package cassette_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/cassette"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+func TestRecorder_WritesRedactedGoldenFiles(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	dir := t.TempDir()
+	rec, err := cassette.NewRecorder(dir, ts.Server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ts.Client("j230")
+	c.HTTPClient = &http.Client{Transport: rec}
+	if _, err := c.Info(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Logs(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"0001-info.json", "0002-logs.json"}
+	var got []string
+	for _, f := range files {
+		got = append(got, filepath.Base(f))
+	}
+	if !cmp.Equal(want, got) {
+		t.Fatal(cmp.Diff(want, got))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "testKey") {
+		t.Errorf("golden file contains the API key:\n%s", data)
+	}
+	if !strings.Contains(string(data), "REDACTED") {
+		t.Errorf("want redacted API key in golden file, got:\n%s", data)
+	}
+}
+
+func TestReplayer_ServesRecordedResponses(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	dir := t.TempDir()
+	rec, err := cassette.NewRecorder(dir, ts.Server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := ts.Client("j230")
+	c.HTTPClient = &http.Client{Transport: rec}
+	want, err := c.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	replay, err := cassette.NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = mikrus.New("anotherKey", "j230")
+	c.HTTPClient = &http.Client{Transport: replay}
+	got, err := c.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("want all interactions replayed, got %d unused", len(unused))
+	}
+}
+
+func TestReplayer_FailsOnUnexpectedRequest(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	golden := `{
+  "request": {"method": "POST", "path": "/info", "form": {"key": ["REDACTED"], "srv": ["j230"]}},
+  "response": {"status_code": 200, "body": "{\"server_id\": \"j230\"}"}
+}`
+	if err := os.WriteFile(filepath.Join(dir, "0001-info.json"), []byte(golden), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := cassette.NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := mikrus.New("key", "j230")
+	c.HTTPClient = &http.Client{Transport: replay}
+	if _, err := c.Logs(); err == nil {
+		t.Fatal("want error for unexpected request, got nil")
+	}
+	if _, err := c.Info(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Info(); err == nil {
+		t.Fatal("want error for request replayed twice, got nil")
+	}
+}
+
+func TestNewReplayer_ErrorsForEmptyDirectory(t *testing.T) {
+	t.Parallel()
+	if _, err := cassette.NewReplayer(t.TempDir()); err == nil {
+		t.Fatal("want error for empty directory, got nil")
+	}
+}
+
+func TestRecorder_RedactsSecretsWithRedactFunc(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	dir := t.TempDir()
+	rec, err := cassette.NewRecorder(dir, ts.Server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Redact = (&mikrus.Redactor{}).Redact
+	c := ts.Client("j230")
+	c.HTTPClient = &http.Client{Transport: rec}
+	out, err := c.Exec("echo password=hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "password=hunter2\n" {
+		t.Errorf("want unredacted output returned to the client, got %q", out)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "0001-exec.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("golden file contains the password:\n%s", data)
+	}
+}
+
+func TestReplayer_MatchesRequestsRecordedWithRedactFunc(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	dir := t.TempDir()
+	redact := (&mikrus.Redactor{}).Redact
+	rec, err := cassette.NewRecorder(dir, ts.Server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Redact = redact
+	c := ts.Client("j230")
+	c.HTTPClient = &http.Client{Transport: rec}
+	if _, err := c.Exec("echo password=hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := cassette.NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.Redact = redact
+	c.HTTPClient = &http.Client{Transport: replay}
+	out, err := c.Exec("echo password=hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("want redacted output replayed, got %q", out)
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("want all interactions replayed, got %v", unused)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/qba73/mikrus/apply"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFile   string
+	applyDryRun bool
+	applyOutput string
+	applyState  string
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "converge servers to the desired state",
+	Long: `Apply reads the desired state of servers from a YAML file, prints
+the plan of changes needed and makes them. Servers are matched to
+configured profiles by server ID.
+
+  servers:
+    - id: j230
+      domains:
+        20230: app.example.com
+      sshKeys:
+        - ~/.ssh/id_ed25519.pub
+      boost:
+        every: 24h
+
+The API does not report assigned domains or uploaded keys, so changes
+are recorded in a local state file and only new ones are made.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := apply.LoadConfig(applyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		statePath := applyState
+		if statePath == "" {
+			if statePath, err = dataDir("apply-state.json"); err != nil {
+				log.Fatal(err)
+			}
+		}
+		state, err := apply.LoadState(statePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		list, err := selectedProfiles(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		byID := map[string]profile{}
+		for _, p := range list {
+			if _, ok := byID[p.SrvID]; !ok {
+				byID[p.SrvID] = p
+			}
+		}
+
+		now := time.Now()
+		var plan apply.Plan
+		plans := map[string]apply.Plan{}
+		for _, s := range cfg.Servers {
+			p, ok := byID[s.ID]
+			if !ok {
+				log.Fatalf("no profile configured for server %q", s.ID)
+			}
+			c := p.client()
+			sp, err := apply.Compute(s, *state.Server(s.ID), &c, now)
+			if err != nil {
+				log.Fatal(err)
+			}
+			plans[s.ID] = sp
+			plan.Changes = append(plan.Changes, sp.Changes...)
+		}
+		switch applyOutput {
+		case "text":
+			fmt.Print(plan)
+		case "json":
+			data, err := json.MarshalIndent(plan, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(data))
+		default:
+			log.Fatalf("invalid output %q, want text or json", applyOutput)
+		}
+		if applyDryRun || len(plan.Changes) == 0 {
+			return
+		}
+
+		for _, s := range cfg.Servers {
+			sp := plans[s.ID]
+			if len(sp.Changes) == 0 {
+				continue
+			}
+			c := byID[s.ID].client()
+			err := apply.Apply(sp, state.Server(s.ID), &c, time.Now())
+			// Record changes made before a failure, so they are
+			// not repeated by the next run.
+			if err := state.Save(statePath); err != nil {
+				log.Fatal(err)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Fprintf(os.Stderr, "server %s: %d changes applied\n", s.ID, len(sp.Changes))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "mikrus.yaml", "Desired state file")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print the plan without making changes")
+	applyCmd.Flags().StringVarP(&applyOutput, "output", "o", "text", "Plan format: text or json")
+	applyCmd.RegisterFlagCompletionFunc("output", completeValues("text", "json"))
+	applyCmd.Flags().StringVar(&applyState, "state", "", "State file, apply-state.json in the data directory by default")
+}
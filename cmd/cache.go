@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/qba73/mikrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var noCache bool
+
+// cache is the response cache shared by all clients. It is nil when
+// caching is disabled.
+var cache *mikrus.DiskCache
+
+// setupCache opens the disk cache of API responses, unless disabled
+// with --no-cache or the cache: false setting. Responses are not
+// cached when API calls are recorded or replayed.
+func setupCache() error {
+	viper.SetDefault("cache", true)
+	if noCache || !viper.GetBool("cache") || recordDir != "" || replayDir != "" {
+		return nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	c, err := mikrus.OpenDiskCache(dir)
+	if err != nil {
+		return err
+	}
+	cache = c
+	return nil
+}
+
+func cacheDir() (string, error) {
+	return dataDir("cache")
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "manage the cache of API responses",
+	Long: `Responses of read-only API calls, like server info, the list of
+servers and logs, are cached in the data directory for a short time,
+so repeated commands do not hit the rate-limited API. Cached responses
+of a server are dropped after tasks like restart. Use --no-cache to
+bypass the cache, or set cache: false in the config file.`,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "remove all cached API responses",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := cacheDir()
+		if err != nil {
+			log.Fatal(err)
+		}
+		c, err := mikrus.OpenDiskCache(dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := c.Clear(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Cache cleared")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"slices"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/logarchive"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout limits API calls made to complete arguments,
+// so a slow API does not block the shell.
+const completionTimeout = 3 * time.Second
+
+// completionClient returns a client of the profile selected on the
+// command line being completed. Responses are cached, so repeated
+// completions do not call the API.
+func completionClient() (profile, mikrus.Client, bool) {
+	p, err := currentProfile()
+	if err != nil || p.APIKey == "" {
+		return profile{}, mikrus.Client{}, false
+	}
+	c := p.client()
+	c.HTTPClient.Timeout = completionTimeout
+	return p, c, true
+}
+
+// completeServerIDs completes IDs of servers of configured profiles
+// and servers of the account.
+func completeServerIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	var ids []cobra.Completion
+	seen := map[string]bool{}
+	add := func(id, desc string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		ids = append(ids, cobra.CompletionWithDesc(id, desc))
+	}
+	list, _ := profiles()
+	for _, p := range list {
+		add(p.SrvID, "profile "+p.Name)
+	}
+	if _, c, ok := completionClient(); ok {
+		servers, _ := c.Servers()
+		for _, s := range servers {
+			add(s.ServerID, s.ServerName)
+		}
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles completes names of configured profiles.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	list, _ := profiles()
+	names := make([]cobra.Completion, 0, len(list))
+	for _, p := range list {
+		names = append(names, cobra.CompletionWithDesc(p.Name, p.SrvID))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLogIDs completes IDs of recent log entries of the server
+// and entries in the local archive, newest first.
+func completeLogIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	p, c, ok := completionClient()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	logs, _ := c.Logs()
+	if dir, err := dataDir("logs/archive"); err == nil {
+		if archive, err := logarchive.Open(dir); err == nil {
+			archived, _ := archive.Search(p.SrvID, logarchive.Query{})
+			logs = append(logs, archived...)
+		}
+	}
+	var ids []cobra.Completion
+	seen := map[string]bool{}
+	for _, l := range logs {
+		if seen[l.ID] {
+			continue
+		}
+		seen[l.ID] = true
+		ids = append(ids, cobra.CompletionWithDesc(l.ID, l.Task+" "+l.WhenCreated))
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeValues returns a completion function of the flag with
+// a fixed set of values, like output formats.
+func completeValues(values ...string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(slices.Clone(values), cobra.ShellCompDirectiveNoFileComp)
+}
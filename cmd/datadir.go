@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+// dataDir returns the directory where mikctl keeps its local data,
+// like stats history. It is the dataDir setting when configured,
+// and $XDG_DATA_HOME/mikctl or ~/.local/share/mikctl otherwise.
+func dataDir(name string) (string, error) {
+	if dir := viper.GetString("dataDir"); dir != "" {
+		return filepath.Join(dir, name), nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "mikctl", name), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "mikctl", name), nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/qba73/mikrus/exporter"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterListen   string
+	exporterInterval time.Duration
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "run Prometheus exporter for configured servers",
+	Long: `Exporter runs an HTTP server exposing memory, disk, load, uptime,
+process and expiry metrics of all configured servers, and counts,
+errors and latency of Mikrus API calls, in the Prometheus format
+at /metrics. The Mikrus API is queried at most once per interval.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exporterInterval <= 0 {
+			log.Fatalf("invalid interval %s, want a positive duration", exporterInterval)
+		}
+		list, err := selectedProfiles(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		calls := &exporter.CallMetrics{}
+		targets := make([]exporter.Target, 0, len(list))
+		for _, p := range list {
+			c := p.client()
+			c.Metrics = calls
+			targets = append(targets, exporter.Target{ServerID: p.SrvID, Source: &c})
+		}
+		e, err := exporter.New(targets, exporterInterval)
+		if err != nil {
+			log.Fatal(err)
+		}
+		e.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
+		e.Calls = calls
+		if cache != nil {
+			e.CacheStats = cache.Stats
+		}
+		go e.Run(context.Background())
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", e)
+		log.Printf("serving metrics at %s/metrics", exporterListen)
+		log.Fatal(http.ListenAndServe(exporterListen, mux))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exporterCmd)
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", ":9494", "Address to serve metrics on")
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", time.Minute, "How often to query the Mikrus API")
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/qba73/mikrus/history"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyAll      bool
+	historyInterval time.Duration
+	historyRaw      time.Duration
+	historyMaxAge   time.Duration
+	historyMetrics  string
+	historySince    time.Duration
+	historyUntil    time.Duration
+	historyCSV      bool
+	historyWidth    int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "collect and show history of server statistics",
+	Long: `History keeps snapshots of server statistics in local files,
+so memory, disk and load can be inspected over time.`,
+}
+
+var historyCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "save a snapshot of server statistics",
+	Long: `Collect saves a snapshot of statistics of the server, or of all
+configured servers with --all. With --interval it keeps collecting
+until interrupted. Old snapshots are downsampled to hourly averages
+and eventually removed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := openHistory()
+		list, err := selectedProfiles(historyAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		retention := history.Retention{Raw: historyRaw, Resolution: time.Hour, MaxAge: historyMaxAge}
+		for {
+			for _, p := range list {
+				c := p.client()
+				stats, err := c.Stats()
+				if err != nil {
+					log.Printf("%s: %v", p.SrvID, err)
+					continue
+				}
+				now := time.Now()
+				if err := store.Append(p.SrvID, history.NewSnapshot(now, stats)); err != nil {
+					log.Fatal(err)
+				}
+				if err := store.Compact(p.SrvID, retention, now); err != nil {
+					log.Fatal(err)
+				}
+			}
+			if historyInterval <= 0 {
+				return
+			}
+			time.Sleep(historyInterval)
+		}
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show history of server statistics",
+	Long: `Show prints sparklines of memory, disk and load of the server, or of
+all servers with --all, over the requested time range. Use --csv to
+export the snapshots instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		store := openHistory()
+		var metrics []history.Metric
+		for _, name := range strings.Split(historyMetrics, ",") {
+			m, err := history.LookupMetric(strings.TrimSpace(name))
+			if err != nil {
+				log.Fatal(err)
+			}
+			metrics = append(metrics, m)
+		}
+		list, err := selectedProfiles(historyAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		now := time.Now()
+		var until time.Time
+		if historyUntil > 0 {
+			until = now.Add(-historyUntil)
+		}
+		csv := history.NewCSVWriter(os.Stdout)
+		for _, p := range list {
+			snaps, err := store.Query(p.SrvID, now.Add(-historySince), until)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if historyCSV {
+				if err := csv.Write(p.SrvID, snaps); err != nil {
+					log.Fatal(err)
+				}
+				continue
+			}
+			fmt.Printf("Server ID: %s (%d snapshots)\n", p.SrvID, len(snaps))
+			for _, m := range metrics {
+				fmt.Println(m.Summary(snaps, historyWidth))
+			}
+			fmt.Println()
+		}
+		if historyCSV {
+			if err := csv.Flush(); err != nil {
+				log.Fatal(err)
+			}
+		}
+	},
+}
+
+func openHistory() *history.Store {
+	dir, err := dataDir("history")
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := history.Open(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return store
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.PersistentFlags().BoolVar(&historyAll, "all", false, "Use all configured servers")
+
+	historyCmd.AddCommand(historyCollectCmd)
+	historyCollectCmd.Flags().DurationVar(&historyInterval, "interval", 0, "Keep collecting snapshots at the interval")
+	historyCollectCmd.Flags().DurationVar(&historyRaw, "keep-raw", history.DefaultRetention.Raw, "How long to keep snapshots before downsampling them")
+	historyCollectCmd.Flags().DurationVar(&historyMaxAge, "keep", history.DefaultRetention.MaxAge, "How long to keep downsampled snapshots")
+
+	historyCmd.AddCommand(historyShowCmd)
+	historyShowCmd.Flags().StringVar(&historyMetrics, "metric", "memory,disk,load15", "Comma separated list of metrics to show")
+	historyShowCmd.Flags().DurationVar(&historySince, "since", 24*time.Hour, "Show snapshots newer than the duration")
+	historyShowCmd.Flags().DurationVar(&historyUntil, "until", 0, "Show snapshots older than the duration")
+	historyShowCmd.Flags().BoolVar(&historyCSV, "csv", false, "Export snapshots as CSV")
+	historyShowCmd.Flags().IntVar(&historyWidth, "width", 60, "Maximum sparkline width")
+}
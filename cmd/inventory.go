@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/inventory"
+	"github.com/spf13/cobra"
+)
+
+var (
+	inventoryFormat string
+	inventoryList   bool
+	inventoryHost   string
+	inventoryPrefix string
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "print an Ansible inventory of all servers",
+	Long: `Inventory prints an Ansible inventory of servers associated with
+API keys of all configured profiles. Hosts get connection variables
+derived from server IDs and the ssh config section (see mikctl ssh
+--help), and belong to groups by RAM, disk and Pro status.
+
+With --list and --host it behaves as an Ansible dynamic inventory
+script, for example wrapped in an executable file:
+
+  #!/bin/sh
+  exec mikctl inventory "$@"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		inv := buildInventory()
+		switch {
+		case inventoryList:
+			if err := inv.WriteJSON(os.Stdout); err != nil {
+				log.Fatal(err)
+			}
+			return
+		case cmd.Flags().Changed("host"):
+			// Host variables are returned in _meta of --list,
+			// so Ansible does not need to call --host.
+			vars, ok := inv.HostVars(inventoryHost)
+			if !ok {
+				vars = inventory.Vars{}
+			}
+			data, err := json.Marshal(vars)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(string(data))
+			return
+		}
+		var err error
+		switch inventoryFormat {
+		case "ansible-ini":
+			err = inv.WriteINI(os.Stdout)
+		case "ansible-yaml":
+			err = inv.WriteYAML(os.Stdout)
+		case "json":
+			err = inv.WriteJSON(os.Stdout)
+		default:
+			log.Fatalf("invalid format %q, want ansible-ini, ansible-yaml or json", inventoryFormat)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// buildInventory returns the inventory of servers of all profiles.
+// Servers are listed once even if several profiles share an account.
+func buildInventory() inventory.Inventory {
+	list, err := selectedProfiles(true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var servers []mikrus.ServerShort
+	seen := map[string]bool{}
+	info := map[string]mikrus.Server{}
+	for _, p := range list {
+		c := p.client()
+		ss, err := c.Servers()
+		if err != nil {
+			log.Printf("%s: %v", p.Name, err)
+			continue
+		}
+		for _, s := range ss {
+			if !seen[s.ServerID] {
+				seen[s.ServerID] = true
+				servers = append(servers, s)
+			}
+		}
+		if s, err := c.Info(); err == nil {
+			info[s.ServerID] = s
+		}
+	}
+	r := sshResolver()
+	r.AliasPrefix = inventoryPrefix
+	return inventory.Build(servers, info, r)
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryCmd)
+	inventoryCmd.Flags().StringVar(&inventoryFormat, "format", "ansible-ini", "Output format: ansible-ini, ansible-yaml or json")
+	inventoryCmd.RegisterFlagCompletionFunc("format", completeValues("ansible-ini", "ansible-yaml", "json"))
+	inventoryCmd.Flags().BoolVar(&inventoryList, "list", false, "Print the inventory as an Ansible dynamic inventory script")
+	inventoryCmd.Flags().StringVar(&inventoryHost, "host", "", "Print variables of the host as an Ansible dynamic inventory script")
+	inventoryCmd.Flags().StringVar(&inventoryPrefix, "prefix", "", "Prefix of inventory host names")
+	inventoryCmd.MarkFlagsMutuallyExclusive("list", "host")
+}
@@ -1,27 +1,303 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/logarchive"
+	"github.com/qba73/mikrus/logtail"
 	"github.com/spf13/cobra"
 )
 
+var (
+	logsFollow   bool
+	logsNew      bool
+	logsInterval time.Duration
+	logsSince    time.Duration
+	logsTask     string
+	logsOutput   string
+	logsAll      bool
+	logsFrom     string
+	logsTo       string
+)
+
 // logsCmd represents the logs command
 var logsCmd = &cobra.Command{
-	Use:   "logs",
+	Use:   "logs [id]",
 	Short: "logs lists log entries for the server",
 	Long: `Logs lists last 10 log entries for the server
-assiociated with the API key and server name`,
+assiociated with the API key and server name, or the entry with the ID,
+looked up in the last entries and the local archive.
+
+With --follow it keeps polling and prints new entries as they appear,
+and entries of pending tasks once they are done. With --new it prints
+only entries not printed by previous --new or --follow runs, which is
+useful from cron. Printed entries are remembered in the data directory.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		logs, err := client.Logs()
+		if logsOutput != "text" && logsOutput != "json" {
+			log.Fatalf("invalid output %q, want text or json", logsOutput)
+		}
+		if len(args) == 1 {
+			if logsFollow || logsNew {
+				log.Fatal("--follow and --new can't be used with a log entry ID")
+			}
+			l, err := findLog(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			if logsOutput == "json" {
+				printJSONLine(l)
+				return
+			}
+			fmt.Print(mikrus.Logs{l})
+			return
+		}
+		filter := logtail.Filter{Task: logsTask}
+		if logsSince > 0 {
+			filter.Since = time.Now().Add(-logsSince)
+		}
+		if !logsFollow && !logsNew {
+			logs, err := client.Logs()
+			if err != nil {
+				log.Fatal(err)
+			}
+			var selected mikrus.Logs
+			for _, l := range logs {
+				if filter.Match(l) {
+					selected = append(selected, l)
+				}
+			}
+			if logsOutput == "json" {
+				for _, l := range selected {
+					printJSONLine(l)
+				}
+				return
+			}
+			fmt.Println(selected)
+			return
+		}
+
+		p, err := currentProfile()
+		if err != nil {
+			log.Fatal(err)
+		}
+		path, err := dataDir(filepath.Join("logs", p.SrvID+".json"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		cursor, err := logtail.LoadCursor(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tail := &logtail.Tail{
+			Source:   uncached(&client),
+			Filter:   filter,
+			Cursor:   cursor,
+			ErrorLog: log.New(os.Stderr, "", log.LstdFlags),
+		}
+		if logsNew {
+			events, err := tail.Poll()
+			if err != nil {
+				log.Fatal(err)
+			}
+			for _, e := range events {
+				printEvent(e)
+			}
+			if err := cursor.Save(path); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		err = tail.Follow(ctx, logsInterval, func(e logtail.Event) error {
+			printEvent(e)
+			return cursor.Save(path)
+		})
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Println(logs)
 	},
 }
 
+// findLog returns the log entry of the current server with the ID
+// from the last entries returned by the API or the local archive.
+func findLog(id string) (mikrus.Log, error) {
+	logs, err := client.Logs()
+	if err != nil {
+		return mikrus.Log{}, err
+	}
+	for _, l := range logs {
+		if l.ID == id {
+			return l, nil
+		}
+	}
+	p, err := currentProfile()
+	if err != nil {
+		return mikrus.Log{}, err
+	}
+	archived, err := openLogArchive().Search(p.SrvID, logarchive.Query{})
+	if err != nil {
+		return mikrus.Log{}, err
+	}
+	for _, l := range archived {
+		if l.ID == id {
+			return l, nil
+		}
+	}
+	return mikrus.Log{}, fmt.Errorf("unknown log entry %q", id)
+}
+
+func printEvent(e logtail.Event) {
+	if logsOutput == "json" {
+		printJSONLine(e)
+		return
+	}
+	fmt.Print(mikrus.Logs{e.Log})
+}
+
+func printJSONLine(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(data))
+}
+
+var logsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "save log entries to the local archive",
+	Long: `Sync merges the most recent log entries of the server, or of all
+configured servers with --all, into the local archive, so entries
+are kept after they drop out of the last 10 entries returned by
+the API. Run it regularly, like from cron.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		archive := openLogArchive()
+		list, err := selectedProfiles(logsAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range list {
+			c := p.client()
+			logs, err := c.Logs()
+			if err != nil {
+				log.Printf("%s: %v", p.SrvID, err)
+				continue
+			}
+			added, updated, err := archive.Merge(p.SrvID, logs)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s: %d added, %d updated\n", p.SrvID, added, updated)
+		}
+	},
+}
+
+var logsSearchCmd = &cobra.Command{
+	Use:   "search [words...]",
+	Short: "search log entries in the local archive",
+	Long: `Search prints archived log entries of the server, or of all
+configured servers with --all, newest first. Entries can be selected
+by task, creation date and words in their output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if logsOutput != "text" && logsOutput != "json" {
+			log.Fatalf("invalid output %q, want text or json", logsOutput)
+		}
+		q := logarchive.Query{Task: logsTask, Text: strings.Join(args, " ")}
+		var err error
+		if q.From, err = parseDate(logsFrom, false); err != nil {
+			log.Fatal(err)
+		}
+		if q.To, err = parseDate(logsTo, true); err != nil {
+			log.Fatal(err)
+		}
+		if logsSince > 0 {
+			q.From = time.Now().Add(-logsSince)
+		}
+		archive := openLogArchive()
+		list, err := selectedProfiles(logsAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range list {
+			logs, err := archive.Search(p.SrvID, q)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if logsOutput == "json" {
+				for _, l := range logs {
+					printJSONLine(l)
+				}
+				continue
+			}
+			fmt.Print(logs)
+		}
+	},
+}
+
+// parseDate parses a date like "2024-06-05" or "2024-06-05 10:00:00".
+// Dates without time mean the start of the day, or the end of the day
+// when end is true. Empty string returns zero time.
+func parseDate(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation(time.DateTime, s, mikrus.Location); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, mikrus.Location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD or YYYY-MM-DD HH:MM:SS", s)
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+func openLogArchive() *logarchive.Archive {
+	dir, err := dataDir("logs/archive")
+	if err != nil {
+		log.Fatal(err)
+	}
+	archive, err := logarchive.Open(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return archive
+}
+
 func init() {
 	rootCmd.AddCommand(logsCmd)
+	logsCmd.ValidArgsFunction = completeLogIDs
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new log entries")
+	logsCmd.Flags().BoolVar(&logsNew, "new", false, "Print only entries not printed before")
+	logsCmd.Flags().DurationVar(&logsInterval, "interval", 30*time.Second, "Polling interval with --follow")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0, "Print only entries created within the duration, like 24h")
+	logsCmd.Flags().StringVar(&logsTask, "task", "", "Print only entries of the task, like restart")
+	logsCmd.Flags().StringVarP(&logsOutput, "output", "o", "text", "Output format: text or json (JSON lines)")
+	logsCmd.RegisterFlagCompletionFunc("output", completeValues("text", "json"))
+	logsCmd.MarkFlagsMutuallyExclusive("follow", "new")
+
+	logsCmd.AddCommand(logsSyncCmd)
+	logsSyncCmd.Flags().BoolVar(&logsAll, "all", false, "Sync logs of all configured servers")
+
+	logsCmd.AddCommand(logsSearchCmd)
+	logsSearchCmd.Flags().BoolVar(&logsAll, "all", false, "Search logs of all configured servers")
+	logsSearchCmd.Flags().StringVar(&logsTask, "task", "", "Print only entries of the task, like restart")
+	logsSearchCmd.Flags().StringVar(&logsFrom, "from", "", "Print only entries created on or after the date")
+	logsSearchCmd.Flags().StringVar(&logsTo, "to", "", "Print only entries created on or before the date")
+	logsSearchCmd.Flags().DurationVar(&logsSince, "since", 0, "Print only entries created within the duration, like 720h")
+	logsSearchCmd.Flags().StringVarP(&logsOutput, "output", "o", "text", "Output format: text or json (JSON lines)")
+	logsSearchCmd.RegisterFlagCompletionFunc("output", completeValues("text", "json"))
+	logsSearchCmd.MarkFlagsMutuallyExclusive("from", "since")
 }
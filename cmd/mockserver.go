@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/qba73/mikrus/mikrustest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mockListen   string
+	mockScenario string
+)
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "run a local mock of the Mikrus API",
+	Long: `Mock-server runs a local, stateful mock of the Mikrus API for
+development and testing. The servers, latency, injected errors and
+rate limits are read from a YAML scenario file. Without a scenario
+the mock serves server j230 with API key testKey.
+
+Point mikctl at the mock with the --url flag:
+
+  mikctl mock-server --listen :8080 &
+  mikctl --url http://localhost:8080 --apiKey testKey --srvID j230 logs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sc := mikrustest.DefaultScenario()
+		if mockScenario != "" {
+			var err error
+			sc, err = mikrustest.LoadScenario(mockScenario)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		h, err := mikrustest.NewHandler(sc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("serving mock Mikrus API at %s", mockListen)
+		log.Fatal(http.ListenAndServe(mockListen, h))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+	mockServerCmd.Flags().StringVar(&mockListen, "listen", ":8080", "Address to serve the mock API on")
+	mockServerCmd.Flags().StringVar(&mockScenario, "scenario", "", "Path to the YAML scenario file")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/qba73/mikrus"
+	"github.com/spf13/viper"
+)
+
+const defaultProfile = "default"
+
+// debugLogger prints traces of API calls requested with --debug.
+var debugLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// profile represents credentials of a single Mikrus server.
+type profile struct {
+	Name   string
+	APIKey string `mapstructure:"apiKey"`
+	SrvID  string `mapstructure:"srvID"`
+}
+
+// client returns a Mikrus client for the profile.
+func (p profile) client() mikrus.Client {
+	c := mikrus.New(p.APIKey, p.SrvID)
+	if u := viper.GetString("url"); u != "" {
+		c.URL = u
+	}
+	if transport != nil {
+		c.HTTPClient.Transport = transport
+	}
+	if cache != nil {
+		c.Cache = cache
+	}
+	if debug {
+		c.Logger = debugLogger
+	}
+	c.Redactor = redactor
+	c.MaxBodySize = viper.GetInt64("maxBodySize")
+	return c
+}
+
+// profiles returns all servers configured for mikctl. The top-level
+// apiKey and srvID settings form the default profile, the others
+// are read from the profiles section of the config file:
+//
+//	profiles:
+//	  backup:
+//	    apiKey: XXX
+//	    srvID: YYY
+func profiles() ([]profile, error) {
+	var list []profile
+	if viper.GetString("apiKey") != "" || viper.GetString("srvID") != "" {
+		list = append(list, profile{
+			Name:   defaultProfile,
+			APIKey: viper.GetString("apiKey"),
+			SrvID:  viper.GetString("srvID"),
+		})
+	}
+	configured := map[string]profile{}
+	if err := viper.UnmarshalKey("profiles", &configured); err != nil {
+		return nil, fmt.Errorf("reading profiles: %w", err)
+	}
+	names := make([]string, 0, len(configured))
+	for name := range configured {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		p := configured[name]
+		p.Name = name
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// currentProfile returns the profile selected with the --profile flag,
+// or the default profile when the flag is not set.
+func currentProfile() (profile, error) {
+	if profileName == "" {
+		return profile{
+			Name:   defaultProfile,
+			APIKey: viper.GetString("apiKey"),
+			SrvID:  viper.GetString("srvID"),
+		}, nil
+	}
+	list, err := profiles()
+	if err != nil {
+		return profile{}, err
+	}
+	for _, p := range list {
+		if strings.EqualFold(p.Name, profileName) {
+			return p, nil
+		}
+	}
+	return profile{}, fmt.Errorf("unknown profile %q", profileName)
+}
+
+// selectedProfiles returns all configured profiles when all is true,
+// and the current profile otherwise.
+func selectedProfiles(all bool) ([]profile, error) {
+	if !all {
+		p, err := currentProfile()
+		if err != nil {
+			return nil, err
+		}
+		return []profile{p}, nil
+	}
+	list, err := profiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no servers configured")
+	}
+	return list, nil
+}
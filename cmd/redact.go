@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/qba73/mikrus"
+	"github.com/spf13/viper"
+)
+
+// redactor removes API keys of all profiles, passwords and secrets
+// matching patterns from the config file from errors, debug output
+// and recorded API calls:
+//
+//	redact:
+//	  - 'ghp_\w+'
+//	  - 'PGPASSWORD=(\S+)'
+var redactor *mikrus.Redactor
+
+// setupRedactor creates the redactor from the config file.
+func setupRedactor() error {
+	r := &mikrus.Redactor{}
+	for _, expr := range viper.GetStringSlice("redact") {
+		p, err := regexp.Compile(expr)
+		if err != nil {
+			return fmt.Errorf("invalid redact pattern %q: %w", expr, err)
+		}
+		r.Patterns = append(r.Patterns, p)
+	}
+	list, err := profiles()
+	if err != nil {
+		return err
+	}
+	for _, p := range list {
+		r.Secrets = append(r.Secrets, p.APIKey)
+	}
+	for _, key := range []string{"dashboard.token", "dashboard.password"} {
+		if s := viper.GetString(key); s != "" {
+			r.Secrets = append(r.Secrets, s)
+		}
+	}
+	redactor = r
+	return nil
+}
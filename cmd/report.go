@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/qba73/mikrus/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportOutput        string
+	reportPrevious      string
+	reportNoSave        bool
+	reportExpiryDays    int
+	reportDiskThreshold float64
+	reportLogs          int
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "print a summary report of all servers",
+	Long: `Report prints a summary of servers of all configured profiles: their
+parameters, expiry, memory and disk usage, and recent tasks, totals
+across all servers, warnings about servers expiring soon, with full
+disks or failed tasks, and changes since the previous report.
+
+Every report is saved as report.json in the data directory, so the
+next one can list changes since it, unless --no-save is set. Use
+--previous to compare with another saved report; it is left intact.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if reportOutput != "md" && reportOutput != "html" && reportOutput != "json" {
+			log.Fatalf("invalid output %q, want md, html or json", reportOutput)
+		}
+		// Zero values stand for defaults in report.Config.
+		if reportExpiryDays <= 0 {
+			log.Fatalf("invalid expiry days %d, want a positive number", reportExpiryDays)
+		}
+		if reportDiskThreshold <= 0 {
+			log.Fatalf("invalid disk threshold %g, want a positive percentage", reportDiskThreshold)
+		}
+		if reportLogs <= 0 {
+			log.Fatalf("invalid number of logs %d, want a positive number", reportLogs)
+		}
+		list, err := selectedProfiles(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cfg := report.Config{
+			ExpiryDays:    reportExpiryDays,
+			DiskThreshold: reportDiskThreshold,
+			Logs:          reportLogs,
+		}
+		keys := map[string]bool{}
+		servers := map[string]bool{}
+		for _, p := range list {
+			c := p.client()
+			if !keys[p.APIKey] {
+				keys[p.APIKey] = true
+				cfg.Accounts = append(cfg.Accounts, &c)
+			}
+			if !servers[p.SrvID] {
+				servers[p.SrvID] = true
+				cfg.Targets = append(cfg.Targets, report.Target{ServerID: p.SrvID, Source: &c})
+			}
+		}
+
+		path, err := dataDir("report.json")
+		if err != nil {
+			log.Fatal(err)
+		}
+		previous := path
+		if reportPrevious != "" {
+			previous = reportPrevious
+		}
+		prev, err := report.Load(previous)
+		if err != nil {
+			log.Fatal(err)
+		}
+		r := report.Build(cfg, time.Now())
+		if prev != nil {
+			r.Compare(prev)
+		}
+		switch reportOutput {
+		case "md":
+			err = r.WriteMarkdown(os.Stdout)
+		case "html":
+			err = r.WriteHTML(os.Stdout)
+		case "json":
+			err = r.WriteJSON(os.Stdout)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if reportNoSave {
+			return
+		}
+		if err := r.Save(path); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringVarP(&reportOutput, "output", "o", "md", "Report format: md, html or json")
+	reportCmd.RegisterFlagCompletionFunc("output", completeValues("md", "html", "json"))
+	reportCmd.Flags().StringVar(&reportPrevious, "previous", "", "Previous report to list changes since, report.json in the data directory by default")
+	reportCmd.Flags().BoolVar(&reportNoSave, "no-save", false, "Do not save the report as the previous one")
+	reportCmd.Flags().IntVar(&reportExpiryDays, "expiry-days", report.DefaultExpiryDays, "Warn about servers expiring within the number of days")
+	reportCmd.Flags().Float64Var(&reportDiskThreshold, "disk-threshold", report.DefaultDiskThreshold, "Warn about servers with disk usage above the percentage")
+	reportCmd.Flags().IntVar(&reportLogs, "logs", report.DefaultLogs, "Number of recent log entries of every server")
+}
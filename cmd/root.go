@@ -32,9 +32,13 @@ func Execute() {
 }
 
 var (
-	apiKey string
-	srvID  string
-	client mikrus.Client
+	apiKey      string
+	srvID       string
+	profileName string
+	recordDir   string
+	replayDir   string
+	debug       bool
+	client      mikrus.Client
 )
 
 func init() {
@@ -48,7 +52,24 @@ func init() {
 	viper.SetEnvPrefix("mikrus")
 	viper.AutomaticEnv()
 	cobra.OnInitialize(func() {
-		client = mikrus.New(viper.GetString("apiKey"), viper.GetString("srvID"))
+		if err := setupRedactor(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := setupTransport(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := setupCache(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		p, err := currentProfile()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		client = p.client()
 	})
 
 	rootCmd.PersistentFlags().StringVar(&apiKey, "apiKey", "", "Mikrus server API key")
@@ -58,4 +79,21 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&srvID, "srvID", "", "Mikrus server ID")
 	viper.BindPFlag("srvID", rootCmd.PersistentFlags().Lookup("srvID"))
 	viper.BindEnv("apiKey", "MIKRUS_SRV_ID")
+
+	rootCmd.RegisterFlagCompletionFunc("srvID", completeServerIDs)
+
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Name of the server profile from the config file")
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
+	rootCmd.PersistentFlags().String("url", "", "Mikrus API URL")
+	viper.BindPFlag("url", rootCmd.PersistentFlags().Lookup("url"))
+	viper.BindEnv("url", "MIKRUS_URL")
+
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Record API requests and responses to golden files in the directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Replay API responses from golden files in the directory")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Print traces of API calls to stderr")
+
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Do not use cached API responses")
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/qba73/mikrus/dashboard"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	serveListen   string
+	serveReadOnly bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve a web dashboard of servers",
+	Long: `Serve runs a web dashboard and a JSON API at /api/ showing servers
+associated with the API key, their expiry, stats, stats charts from the
+local history (see mikctl history collect) and logs. Servers of all
+configured profiles can be restarted and boosted, unless --read-only
+is set. The dashboard listens on localhost only, unless --listen is set.
+
+Protect the dashboard with a token, sent as a bearer token or as the
+basic auth password, or with a basic auth user and password, set in the
+config file, the MIKRUS_DASHBOARD_TOKEN and MIKRUS_DASHBOARD_PASSWORD
+environment variables, or flags, which other users can see in the
+process list:
+
+  dashboard:
+    token: XXX
+    user: admin
+    password: YYY
+
+Without authentication the dashboard is served only with --read-only.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		list, err := selectedProfiles(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var targets []dashboard.Target
+		seen := map[string]bool{}
+		for _, p := range list {
+			if seen[p.SrvID] {
+				continue
+			}
+			seen[p.SrvID] = true
+			c := p.client()
+			targets = append(targets, dashboard.Target{ServerID: p.SrvID, Source: &c})
+		}
+		auth := dashboard.Auth{
+			Username: viper.GetString("dashboard.user"),
+			Password: viper.GetString("dashboard.password"),
+			Token:    viper.GetString("dashboard.token"),
+		}
+		if auth.Username != "" && auth.Password == "" {
+			log.Fatal("dashboard password is required with user")
+		}
+		if auth.Username == "" && auth.Token == "" {
+			if !serveReadOnly {
+				log.Fatal("dashboard token or user is required, unless --read-only is set")
+			}
+			log.Print("warning: serving the dashboard without authentication")
+		}
+		d := dashboard.New(dashboard.Config{
+			Servers:  &client,
+			Targets:  targets,
+			History:  openHistory(),
+			Auth:     auth,
+			ReadOnly: serveReadOnly,
+			ErrorLog: log.New(os.Stderr, "", log.LstdFlags),
+		})
+		log.Printf("serving dashboard at %s", serveListen)
+		log.Fatal(http.ListenAndServe(serveListen, d))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8080", "Address to serve the dashboard on")
+	serveCmd.Flags().BoolVar(&serveReadOnly, "read-only", false, "Disable restarting and boosting servers")
+
+	serveCmd.Flags().String("token", "", "Token required to access the dashboard, visible in the process list, prefer MIKRUS_DASHBOARD_TOKEN")
+	viper.BindPFlag("dashboard.token", serveCmd.Flags().Lookup("token"))
+	viper.BindEnv("dashboard.token", "MIKRUS_DASHBOARD_TOKEN")
+	serveCmd.Flags().String("user", "", "Basic auth user required to access the dashboard")
+	viper.BindPFlag("dashboard.user", serveCmd.Flags().Lookup("user"))
+	serveCmd.Flags().String("password", "", "Basic auth password of the user, visible in the process list, prefer MIKRUS_DASHBOARD_PASSWORD")
+	viper.BindPFlag("dashboard.password", serveCmd.Flags().Lookup("password"))
+	viper.BindEnv("dashboard.password", "MIKRUS_DASHBOARD_PASSWORD")
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/qba73/mikrus/sshconfig"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	sshHost     string
+	sshUser     string
+	sshIdentity string
+	sshPrint    bool
+	sshPrefix   string
+)
+
+var sshCmd = &cobra.Command{
+	Use:   "ssh [server] [-- command...]",
+	Short: "connect to the server with SSH",
+	Long: `SSH runs the system ssh client connected to the server, the server
+of the current profile by default. The SSH port is derived from the
+server ID. Host names are not reported by the API, so set them in the
+config file for server IDs or their prefixes:
+
+  ssh:
+    user: root
+    hosts:
+      j: srv07.mikr.us
+      a101: srv01.mikr.us
+
+or pass one with --host.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serverID, command := "", args
+		if dash := cmd.ArgsLenAtDash(); dash != 0 && len(args) > 0 {
+			serverID, command = args[0], args[1:]
+		}
+		if serverID == "" {
+			p, err := currentProfile()
+			if err != nil {
+				log.Fatal(err)
+			}
+			serverID = p.SrvID
+		}
+		r := sshResolver()
+		if sshHost != "" {
+			r.Hosts = map[string]string{serverID: sshHost}
+		}
+		host, err := r.Resolve(serverID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sshArgs := host.Args(command...)
+		if sshPrint {
+			fmt.Println(shellJoin(append([]string{"ssh"}, sshArgs...)))
+			return
+		}
+		c := exec.Command("ssh", sshArgs...)
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			log.Fatal(err)
+		}
+	},
+}
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config",
+	Short: "print ssh_config blocks for all servers",
+	Long: `SSH-config prints ~/.ssh/config blocks for every server associated
+with the API key. Host names are taken from the ssh.hosts config, see
+mikctl ssh --help. Servers with unknown hosts are skipped.
+
+  mikctl ssh-config >> ~/.ssh/config`,
+	Run: func(cmd *cobra.Command, args []string) {
+		servers, err := client.Servers()
+		if err != nil {
+			log.Fatal(err)
+		}
+		r := sshResolver()
+		r.AliasPrefix = sshPrefix
+		var hosts []sshconfig.Host
+		for _, s := range servers {
+			h, err := r.Resolve(s.ServerID)
+			if err != nil {
+				log.Printf("skipping: %v", err)
+				continue
+			}
+			h.ServerName = s.ServerName
+			hosts = append(hosts, h)
+		}
+		if err := sshconfig.WriteConfig(os.Stdout, hosts); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// sshResolver returns the resolver configured with the ssh config
+// section and flags.
+func sshResolver() sshconfig.Resolver {
+	r := sshconfig.Resolver{
+		Hosts:        viper.GetStringMapString("ssh.hosts"),
+		User:         viper.GetString("ssh.user"),
+		IdentityFile: viper.GetString("ssh.identityFile"),
+	}
+	if sshUser != "" {
+		r.User = sshUser
+	}
+	if sshIdentity != "" {
+		r.IdentityFile = sshIdentity
+	}
+	return r
+}
+
+var shellSafeRE = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_~-]+$`)
+
+// shellJoin joins arguments into a command line, quoting them
+// for POSIX shells when needed.
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, a := range args {
+		if !shellSafeRE.MatchString(a) {
+			a = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+		quoted = append(quoted, a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func init() {
+	rootCmd.AddCommand(sshCmd)
+	sshCmd.Flags().StringVar(&sshHost, "host", "", "SSH host name of the server")
+	sshCmd.Flags().BoolVar(&sshPrint, "print", false, "Print the ssh command instead of running it")
+
+	rootCmd.AddCommand(sshConfigCmd)
+	sshConfigCmd.Flags().StringVar(&sshPrefix, "prefix", "mikrus-", "Prefix of host aliases")
+
+	for _, c := range []*cobra.Command{sshCmd, sshConfigCmd} {
+		c.Flags().StringVar(&sshUser, "user", "", "SSH user, root by default")
+		c.Flags().StringVarP(&sshIdentity, "identity", "i", "", "SSH private key file")
+	}
+}
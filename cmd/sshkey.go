@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	sshKeyWait    bool
+	sshKeyTimeout time.Duration
+)
+
+var sshKeyCmd = &cobra.Command{
+	Use:   "ssh-key",
+	Short: "manage SSH keys of the server",
+}
+
+var sshKeyAddCmd = &cobra.Command{
+	Use:   "add [file]",
+	Short: "upload an SSH public key to the server",
+	Long: `Add uploads the OpenSSH public key from the file, ~/.ssh/id_ed25519.pub
+by default, to the server and waits until the server logs report
+the key was added.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ""
+		if len(args) > 0 {
+			path = args[0]
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				log.Fatal(err)
+			}
+			path = filepath.Join(home, ".ssh", "id_ed25519.pub")
+		}
+		key, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		before, err := logIDs(&client)
+		if err != nil {
+			log.Fatal(err)
+		}
+		res, err := client.UploadSSHKey(string(key))
+		if err != nil {
+			log.Fatalf("%s: %v", path, err)
+		}
+		fmt.Printf("Uploading %s\n", path)
+		if !sshKeyWait {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sshKeyTimeout)
+		defer cancel()
+		entry, err := waitForTask(ctx, &client, "kluczssh", res, before)
+		if err != nil {
+			log.Fatal(err)
+		}
+		result := entry.Result()
+		if result.Failed {
+			log.Fatalf("Uploading SSH key failed: %s", result.Message)
+		}
+		fmt.Println(result.Message)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshKeyCmd)
+	sshKeyCmd.AddCommand(sshKeyAddCmd)
+	sshKeyAddCmd.Flags().BoolVar(&sshKeyWait, "wait", true, "Wait for the server logs to report the key was added")
+	sshKeyAddCmd.Flags().DurationVar(&sshKeyTimeout, "timeout", 2*time.Minute, "How long to wait for the key to be added")
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// logIDs returns IDs of the current log entries of the server.
+func logIDs(c *mikrus.Client) (map[string]bool, error) {
+	logs, err := uncached(c).Logs()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(logs))
+	for _, l := range logs {
+		ids[l.ID] = true
+	}
+	return ids, nil
+}
+
+// waitForTask polls logs until the log entry of the task is done.
+// The entry is found by the task ID when the API reported it, and
+// otherwise as the newest entry of the task not present in before.
+func waitForTask(ctx context.Context, c *mikrus.Client, task string, res mikrus.TaskResponse, before map[string]bool) (mikrus.Log, error) {
+	c = uncached(c)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		logs, err := c.Logs()
+		if err != nil {
+			return mikrus.Log{}, err
+		}
+		for _, l := range logs {
+			match := l.ID == res.TaskID
+			if res.TaskID == "" {
+				match = l.Task == task && !before[l.ID]
+			}
+			if match && l.WhenDone != "" {
+				return l, nil
+			}
+			if match {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return mikrus.Log{}, fmt.Errorf("waiting for %s task: %w", task, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// uncached returns a copy of the client bypassing the response cache,
+// for commands waiting for changes.
+func uncached(c *mikrus.Client) *mikrus.Client {
+	fresh := *c
+	fresh.Cache = nil
+	return &fresh
+}
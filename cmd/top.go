@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topAll     bool
+	topLimit   int
+	topSort    string
+	topUser    string
+	topState   string
+	topCommand string
+	topGroup   bool
+	topZombies bool
+	topLong    time.Duration
+	topMinCPU  float64
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "show the heaviest processes on the server",
+	Long: `Top shows processes using the most resources on the server
+associated with the API key and server name, or on all configured
+servers when --all is set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var commandRE *regexp.Regexp
+		if topCommand != "" {
+			re, err := regexp.Compile(topCommand)
+			if err != nil {
+				log.Fatal(err)
+			}
+			commandRE = re
+		}
+		list, err := selectedProfiles(topAll)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, p := range list {
+			c := p.client()
+			stats, err := c.Stats()
+			if err != nil {
+				log.Fatalf("%s: %v", p.SrvID, err)
+			}
+			ps := stats.Processes
+			if topUser != "" {
+				ps = ps.FilterUser(topUser)
+			}
+			if topState != "" {
+				ps = ps.FilterState(topState)
+			}
+			if topZombies {
+				ps = ps.Zombies()
+			}
+			if commandRE != nil {
+				ps = ps.FilterCommand(commandRE)
+			}
+			if topLong > 0 {
+				ps = ps.LongRunning(topLong, topMinCPU)
+			}
+			if len(list) > 1 {
+				fmt.Printf("Server ID: %s\n", p.SrvID)
+			}
+			if topGroup {
+				groups, err := sortGroups(ps.GroupByCommand(), topSort)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Println(groups.Top(topLimit))
+				continue
+			}
+			ps, err = sortProcesses(ps, topSort)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(ps.Top(topLimit))
+		}
+	},
+}
+
+func sortProcesses(ps mikrus.Processes, by string) (mikrus.Processes, error) {
+	switch by {
+	case "cpu":
+		return ps.SortByCPU(), nil
+	case "mem":
+		return ps.SortByMemory(), nil
+	case "rss":
+		return ps.SortByRSS(), nil
+	default:
+		return nil, fmt.Errorf("unknown sort order %q, want cpu, mem or rss", by)
+	}
+}
+
+func sortGroups(g mikrus.ProcessGroups, by string) (mikrus.ProcessGroups, error) {
+	switch by {
+	case "cpu":
+		return g.SortByCPU(), nil
+	case "mem":
+		return g.SortByMemory(), nil
+	case "rss":
+		return g.SortByRSS(), nil
+	default:
+		return nil, fmt.Errorf("unknown sort order %q, want cpu, mem or rss", by)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(topCmd)
+	topCmd.Flags().BoolVar(&topAll, "all", false, "Show processes on all configured servers")
+	topCmd.Flags().IntVarP(&topLimit, "limit", "n", 10, "Number of processes or groups to show")
+	topCmd.Flags().StringVar(&topSort, "sort", "cpu", "Sort processes by cpu, mem or rss")
+	topCmd.RegisterFlagCompletionFunc("sort", completeValues("cpu", "mem", "rss"))
+	topCmd.Flags().StringVar(&topUser, "user", "", "Show only processes owned by the user")
+	topCmd.Flags().StringVar(&topState, "state", "", "Show only processes in the state, e.g. R, S or Z")
+	topCmd.Flags().StringVar(&topCommand, "command", "", "Show only processes with commands matching the regexp")
+	topCmd.Flags().BoolVar(&topGroup, "group", false, "Group processes by command name")
+	topCmd.Flags().BoolVar(&topZombies, "zombies", false, "Show only zombie processes")
+	topCmd.Flags().DurationVar(&topLong, "long-running", 0, "Show only processes that used at least the CPU time, e.g. 1h")
+	topCmd.Flags().Float64Var(&topMinCPU, "min-cpu", 10, "Minimum CPU usage percentage of processes shown with --long-running")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/qba73/mikrus/cassette"
+)
+
+// transport is the HTTP transport shared by all clients. It is nil
+// unless API calls are recorded or replayed.
+var transport http.RoundTripper
+
+// setupTransport configures recording or replaying of API calls
+// requested with the --record and --replay flags. Recorded calls are
+// scrubbed with the redactor, and so are replayed calls before they
+// are matched with recordings.
+func setupTransport() error {
+	switch {
+	case recordDir != "":
+		rec, err := cassette.NewRecorder(recordDir, nil)
+		if err != nil {
+			return err
+		}
+		rec.Redact = redactor.Redact
+		transport = rec
+	case replayDir != "":
+		replay, err := cassette.NewReplayer(replayDir)
+		if err != nil {
+			return err
+		}
+		replay.Redact = redactor.Redact
+		transport = replay
+	}
+	return nil
+}
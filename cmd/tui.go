@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"log"
+	"time"
+
+	"github.com/qba73/mikrus/tui"
+	"github.com/spf13/cobra"
+)
+
+var tuiInterval time.Duration
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "manage servers in an interactive terminal UI",
+	Long: `TUI lists servers associated with the API key and shows details,
+live stats and recent logs of the selected server, refreshed in the
+background. Servers are restarted, boosted and commands are run after
+confirmation.
+
+Every server has its own API key, so stats, logs and tasks are
+available for servers with a configured profile only.
+
+Keys: ↑/↓ select, r restart, b boost, e exec, l logs, R refresh, q quit.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		list, err := profiles()
+		if err != nil {
+			log.Fatal(err)
+		}
+		servers := map[string]tui.Server{}
+		for _, p := range list {
+			if _, ok := servers[p.SrvID]; !ok {
+				c := p.client()
+				servers[p.SrvID] = &c
+			}
+		}
+		err = tui.Run(tui.Config{
+			Servers: &client,
+			Connect: func(id string) (tui.Server, bool) {
+				s, ok := servers[id]
+				return s, ok
+			},
+			Interval: tuiInterval,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+	tuiCmd.Flags().DurationVar(&tuiInterval, "interval", 10*time.Second, "How often to refresh the selected server")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/qba73/mikrus/notify"
+	"github.com/qba73/mikrus/watch"
+	"github.com/spf13/cobra"
+)
+
+var watchConfig string
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "watch servers and send alerts",
+	Long: `Watch polls stats, info and logs of all configured servers and
+sends alerts when conditions described by rules hold, like disk
+usage above 85% for 10 minutes. Alerts are sent once when they
+fire and once when they are resolved. With tasks enabled, it also
+announces tasks that finish while it runs.
+
+Rules and notifiers are read from a YAML file:
+
+  interval: 1m
+  tasks: true
+  rules:
+    - name: disk-full
+      expr: disk_percent > 85
+      for: 10m
+    - name: expiring
+      expr: expiry_days < 14
+  notifiers:
+    - type: stdout
+    - type: webhook
+      url: https://hooks.example.com/mikrus`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := watch.LoadConfig(watchConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var notifiers notify.Multi
+		for _, nc := range cfg.Notifiers {
+			n, err := notify.New(nc)
+			if err != nil {
+				log.Fatal(err)
+			}
+			notifiers = append(notifiers, n)
+		}
+		if len(notifiers) == 0 {
+			notifiers = append(notifiers, notify.Writer{W: os.Stdout})
+		}
+		list, err := selectedProfiles(true)
+		if err != nil {
+			log.Fatal(err)
+		}
+		targets := make([]watch.Target, 0, len(list))
+		for _, p := range list {
+			c := p.client()
+			targets = append(targets, watch.Target{ServerID: p.SrvID, Source: &c})
+		}
+		w, err := watch.New(targets, cfg.Rules, notifiers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w.ErrorLog = log.New(os.Stderr, "", log.LstdFlags)
+		w.NotifyTasks = cfg.Tasks
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		w.Run(ctx, cfg.Interval)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().StringVar(&watchConfig, "config", "watch.yaml", "Path to the YAML file with rules and notifiers")
+}
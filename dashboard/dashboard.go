@@ -0,0 +1,336 @@
+// Package dashboard serves a web dashboard and a JSON API of Mikrus
+// servers: their expiry, stats, stats history and logs.
+//
+// The dashboard can be protected with basic auth or a token, and
+// made read-only, so it can be shared safely.
+package dashboard
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/history"
+)
+
+//go:embed static
+var static embed.FS
+
+// Lister lists servers of the account.
+// *mikrus.Client implements Lister.
+type Lister interface {
+	Servers() (mikrus.Servers, error)
+}
+
+// Source provides information about a single Mikrus server and runs
+// tasks on it. *mikrus.Client implements Source.
+type Source interface {
+	Info() (mikrus.Server, error)
+	Stats() (mikrus.Stats, error)
+	Logs() (mikrus.Logs, error)
+	Restart() (mikrus.TaskResponse, error)
+	Boost() (mikrus.TaskResponse, error)
+}
+
+// History provides snapshots of server statistics.
+// *history.Store implements History.
+type History interface {
+	Query(serverID string, from, to time.Time) ([]history.Snapshot, error)
+}
+
+// Target represents a server managed in the dashboard.
+type Target struct {
+	ServerID string
+	Source   Source
+}
+
+// Auth configures authentication. Requests are authenticated with
+// basic auth when Username is set, and with the Token, sent as
+// a bearer token or as the basic auth password, when Token is set.
+// Zero Auth allows all requests.
+type Auth struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// Config configures the dashboard.
+type Config struct {
+	// Servers lists servers shown in the dashboard.
+	Servers Lister
+
+	// Targets are servers with API keys. Stats, logs and tasks are
+	// available only for them.
+	Targets []Target
+
+	// History provides stats charts. If nil, charts are not shown.
+	History History
+
+	Auth Auth
+
+	// ReadOnly disables restarting and boosting servers.
+	ReadOnly bool
+
+	// ErrorLog specifies an optional logger for errors returned
+	// by the Mikrus API. If nil, errors are not logged.
+	ErrorLog *log.Logger
+}
+
+// Dashboard is an http.Handler serving the web UI at / and the JSON
+// API at /api/.
+type Dashboard struct {
+	cfg     Config
+	targets map[string]Source
+	mux     *http.ServeMux
+}
+
+// New creates a dashboard.
+func New(cfg Config) *Dashboard {
+	d := &Dashboard{
+		cfg:     cfg,
+		targets: map[string]Source{},
+		mux:     http.NewServeMux(),
+	}
+	for _, t := range cfg.Targets {
+		d.targets[t.ServerID] = t.Source
+	}
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	d.mux.Handle("GET /", http.FileServerFS(files))
+	d.mux.HandleFunc("GET /api/config", d.config)
+	d.mux.HandleFunc("GET /api/servers", d.servers)
+	d.mux.HandleFunc("GET /api/servers/{id}", d.info)
+	d.mux.HandleFunc("GET /api/servers/{id}/stats", d.stats)
+	d.mux.HandleFunc("GET /api/servers/{id}/history", d.history)
+	d.mux.HandleFunc("GET /api/servers/{id}/logs", d.logs)
+	d.mux.HandleFunc("POST /api/servers/{id}/restart", d.task(Source.Restart))
+	d.mux.HandleFunc("POST /api/servers/{id}/boost", d.task(Source.Boost))
+	return d
+}
+
+// ServeHTTP implements http.Handler.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !d.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="mikctl", charset="UTF-8"`)
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	d.mux.ServeHTTP(w, r)
+}
+
+func (d *Dashboard) authorized(r *http.Request) bool {
+	a := d.cfg.Auth
+	if a.Username == "" && a.Token == "" {
+		return true
+	}
+	if a.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && equal(token, a.Token) {
+			return true
+		}
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	if a.Username != "" && equal(user, a.Username) && equal(pass, a.Password) {
+		return true
+	}
+	return a.Token != "" && equal(pass, a.Token)
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// ServerSummary describes a server in the list of servers.
+type ServerSummary struct {
+	mikrus.ServerShort
+
+	// Configured reports whether the dashboard has the API key
+	// of the server, so its stats and logs are available.
+	Configured bool `json:"configured"`
+}
+
+// LogEntry is a log entry with its parsed result.
+type LogEntry struct {
+	mikrus.Log
+	Result mikrus.Result `json:"result"`
+}
+
+func (d *Dashboard) config(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]bool{
+		"read_only": d.cfg.ReadOnly,
+		"history":   d.cfg.History != nil,
+	})
+}
+
+func (d *Dashboard) servers(w http.ResponseWriter, r *http.Request) {
+	var list mikrus.Servers
+	if d.cfg.Servers != nil {
+		var err error
+		if list, err = d.cfg.Servers.Servers(); err != nil {
+			d.apiError(w, err)
+			return
+		}
+	}
+	summaries := make([]ServerSummary, 0, len(list))
+	seen := map[string]bool{}
+	for _, s := range list {
+		_, ok := d.targets[s.ServerID]
+		summaries = append(summaries, ServerSummary{ServerShort: s, Configured: ok})
+		seen[s.ServerID] = true
+	}
+	// Targets of other accounts are not listed by the API.
+	for _, t := range d.cfg.Targets {
+		if !seen[t.ServerID] {
+			summaries = append(summaries, ServerSummary{ServerShort: mikrus.ServerShort{ServerID: t.ServerID}, Configured: true})
+			seen[t.ServerID] = true
+		}
+	}
+	writeJSON(w, summaries)
+}
+
+// source returns the source of the server in the request path,
+// writing an error response when the server is unknown.
+func (d *Dashboard) source(w http.ResponseWriter, r *http.Request) (Source, bool) {
+	src, ok := d.targets[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown server "+r.PathValue("id"))
+	}
+	return src, ok
+}
+
+func (d *Dashboard) info(w http.ResponseWriter, r *http.Request) {
+	src, ok := d.source(w, r)
+	if !ok {
+		return
+	}
+	info, err := src.Info()
+	if err != nil {
+		d.apiError(w, err)
+		return
+	}
+	writeJSON(w, info)
+}
+
+func (d *Dashboard) stats(w http.ResponseWriter, r *http.Request) {
+	src, ok := d.source(w, r)
+	if !ok {
+		return
+	}
+	stats, err := src.Stats()
+	if err != nil {
+		d.apiError(w, err)
+		return
+	}
+	writeJSON(w, stats)
+}
+
+func (d *Dashboard) logs(w http.ResponseWriter, r *http.Request) {
+	src, ok := d.source(w, r)
+	if !ok {
+		return
+	}
+	logs, err := src.Logs()
+	if err != nil {
+		d.apiError(w, err)
+		return
+	}
+	entries := make([]LogEntry, 0, len(logs))
+	for _, l := range logs {
+		entries = append(entries, LogEntry{Log: l, Result: l.Result()})
+	}
+	writeJSON(w, entries)
+}
+
+// history serves snapshots taken in the duration given by the since
+// query parameter, 24h by default.
+func (d *Dashboard) history(w http.ResponseWriter, r *http.Request) {
+	if d.cfg.History == nil {
+		writeError(w, http.StatusNotFound, "history is not enabled")
+		return
+	}
+	since := 24 * time.Hour
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		if since, err = time.ParseDuration(s); err != nil || since <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid since parameter")
+			return
+		}
+	}
+	snaps, err := d.cfg.History.Query(r.PathValue("id"), time.Now().Add(-since), time.Time{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if snaps == nil {
+		snaps = []history.Snapshot{}
+	}
+	writeJSON(w, snaps)
+}
+
+// task returns a handler running the task on the server.
+func (d *Dashboard) task(run func(Source) (mikrus.TaskResponse, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if d.cfg.ReadOnly {
+			writeError(w, http.StatusForbidden, "dashboard is read-only")
+			return
+		}
+		if !sameOrigin(r) {
+			writeError(w, http.StatusForbidden, "cross-origin request")
+			return
+		}
+		src, ok := d.source(w, r)
+		if !ok {
+			return
+		}
+		res, err := run(src)
+		if err != nil {
+			d.apiError(w, err)
+			return
+		}
+		writeJSON(w, res)
+	}
+}
+
+// sameOrigin reports whether the request comes from the dashboard
+// itself, or from a client that is not a browser. Browsers send
+// basic auth credentials with cross-site requests too.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return r.Header.Get("Sec-Fetch-Site") != "cross-site"
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// apiError reports an error returned by the Mikrus API.
+func (d *Dashboard) apiError(w http.ResponseWriter, err error) {
+	if d.cfg.ErrorLog != nil {
+		d.cfg.ErrorLog.Print(err)
+	}
+	writeError(w, http.StatusBadGateway, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
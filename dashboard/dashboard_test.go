@@ -0,0 +1,185 @@
+package dashboard_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/dashboard"
+	"github.com/qba73/mikrus/history"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+// newDashboard returns a dashboard of the mock API with servers j230,
+// configured, and a101, and the mock.
+func newDashboard(t *testing.T, cfg dashboard.Config) (*dashboard.Dashboard, *mikrustest.Server) {
+	t.Helper()
+	sc := mikrustest.DefaultScenario()
+	sc.Servers = append(sc.Servers, mikrustest.ServerConfig{ID: "a101", APIKey: "otherKey", Expires: "2025-01-01 00:00:00"})
+	ts := mikrustest.NewServer(sc)
+	t.Cleanup(ts.Close)
+	c := ts.Client("j230")
+	cfg.Servers = &c
+	cfg.Targets = []dashboard.Target{{ServerID: "j230", Source: &c}}
+	return dashboard.New(cfg), ts
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDashboard_ServesEmbeddedWebUI(t *testing.T) {
+	t.Parallel()
+	d, _ := newDashboard(t, dashboard.Config{})
+	rec := serve(d, httptest.NewRequest("GET", "/", nil))
+	body, _ := io.ReadAll(rec.Body)
+	if rec.Code != http.StatusOK || !strings.Contains(string(body), "<title>Mikrus dashboard</title>") {
+		t.Errorf("want index page, got %d: %s", rec.Code, body)
+	}
+	if rec := serve(d, httptest.NewRequest("GET", "/app.js", nil)); rec.Code != http.StatusOK {
+		t.Errorf("want app.js, got status %d", rec.Code)
+	}
+}
+
+func TestDashboard_ListsServersMarkingConfiguredOnes(t *testing.T) {
+	t.Parallel()
+	d, _ := newDashboard(t, dashboard.Config{})
+	var got []dashboard.ServerSummary
+	decode(t, serve(d, httptest.NewRequest("GET", "/api/servers", nil)), &got)
+	want := []dashboard.ServerSummary{
+		{ServerShort: mikrus.ServerShort{ServerID: "j230", Expires: "2026-06-08 00:00:00", ParamRam: "1024", ParamDisk: "10"}, Configured: true},
+		{ServerShort: mikrus.ServerShort{ServerID: "a101", Expires: "2025-01-01 00:00:00", ParamRam: "0", ParamDisk: "0"}},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDashboard_ServesStatsAndLogsOfConfiguredServers(t *testing.T) {
+	t.Parallel()
+	d, _ := newDashboard(t, dashboard.Config{})
+	var stats mikrus.Stats
+	decode(t, serve(d, httptest.NewRequest("GET", "/api/servers/j230/stats", nil)), &stats)
+	if stats.Memory.Total != 1024 {
+		t.Errorf("want total memory 1024, got %d", stats.Memory.Total)
+	}
+	var logs []dashboard.LogEntry
+	decode(t, serve(d, httptest.NewRequest("GET", "/api/servers/j230/logs", nil)), &logs)
+	if len(logs) != 3 || logs[0].Result.Message != "Uploaded SSH key" {
+		t.Errorf("want 3 logs with results, got %+v", logs)
+	}
+	if rec := serve(d, httptest.NewRequest("GET", "/api/servers/a101/stats", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("want status 404 for server without API key, got %d", rec.Code)
+	}
+}
+
+func TestDashboard_ServesHistory(t *testing.T) {
+	t.Parallel()
+	store, err := history.Open(filepath.Join(t.TempDir(), "history"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, age := range []time.Duration{48 * time.Hour, time.Hour} {
+		if err := store.Append("j230", history.Snapshot{Time: now.Add(-age), MemoryUsed: age.Hours()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d, _ := newDashboard(t, dashboard.Config{History: store})
+	var got []history.Snapshot
+	decode(t, serve(d, httptest.NewRequest("GET", "/api/servers/j230/history", nil)), &got)
+	want := []history.Snapshot{{Time: now.Add(-time.Hour), MemoryUsed: 1}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if rec := serve(d, httptest.NewRequest("GET", "/api/servers/j230/history?since=bogus", nil)); rec.Code != http.StatusBadRequest {
+		t.Errorf("want status 400 for invalid since, got %d", rec.Code)
+	}
+}
+
+func TestDashboard_RestartsServer(t *testing.T) {
+	t.Parallel()
+	d, ts := newDashboard(t, dashboard.Config{})
+	var res mikrus.TaskResponse
+	decode(t, serve(d, httptest.NewRequest("POST", "/api/servers/j230/restart", nil)), &res)
+	if res.TaskID == "" || ts.Handler.Calls("restart") != 1 {
+		t.Errorf("want restart task, got %+v", res)
+	}
+}
+
+func TestDashboard_RejectsTasksWhenReadOnly(t *testing.T) {
+	t.Parallel()
+	d, ts := newDashboard(t, dashboard.Config{ReadOnly: true})
+	for _, task := range []string{"restart", "boost"} {
+		if rec := serve(d, httptest.NewRequest("POST", "/api/servers/j230/"+task, nil)); rec.Code != http.StatusForbidden {
+			t.Errorf("%s: want status 403, got %d", task, rec.Code)
+		}
+	}
+	if got := ts.Handler.Calls("restart") + ts.Handler.Calls("amfetamina"); got != 0 {
+		t.Errorf("want no tasks, got %d calls", got)
+	}
+}
+
+func TestDashboard_RejectsCrossOriginTasks(t *testing.T) {
+	t.Parallel()
+	d, ts := newDashboard(t, dashboard.Config{})
+	req := httptest.NewRequest("POST", "http://dash.example.com/api/servers/j230/boost", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	if rec := serve(d, req); rec.Code != http.StatusForbidden {
+		t.Errorf("want status 403, got %d", rec.Code)
+	}
+	req.Header.Set("Origin", "http://dash.example.com")
+	if rec := serve(d, req); rec.Code != http.StatusOK {
+		t.Errorf("want status 200 for same origin, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := ts.Handler.Calls("amfetamina"); got != 1 {
+		t.Errorf("want 1 boost, got %d", got)
+	}
+}
+
+func TestDashboard_RequiresAuthentication(t *testing.T) {
+	t.Parallel()
+	d, _ := newDashboard(t, dashboard.Config{Auth: dashboard.Auth{Username: "admin", Password: "secret", Token: "t0ken"}})
+	tests := []struct {
+		name  string
+		setup func(*http.Request)
+		want  int
+	}{
+		{name: "no credentials", setup: func(*http.Request) {}, want: http.StatusUnauthorized},
+		{name: "basic auth", setup: func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, want: http.StatusOK},
+		{name: "wrong password", setup: func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }, want: http.StatusUnauthorized},
+		{name: "bearer token", setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer t0ken") }, want: http.StatusOK},
+		{name: "token as password", setup: func(r *http.Request) { r.SetBasicAuth("anyone", "t0ken") }, want: http.StatusOK},
+		{name: "wrong token", setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, want: http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest("GET", "/api/config", nil)
+		tc.setup(req)
+		rec := serve(d, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: want status %d, got %d", tc.name, tc.want, rec.Code)
+		}
+		if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: want WWW-Authenticate header", tc.name)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+"use strict";
+
+const state = { config: {}, selected: null, configured: false };
+
+async function api(path, options) {
+  const res = await fetch("api/" + path, options);
+  const body = await res.json();
+  if (!res.ok) {
+    throw new Error(body.error || res.statusText);
+  }
+  return body;
+}
+
+function el(tag, attrs, ...children) {
+  const e = document.createElement(tag);
+  Object.assign(e, attrs);
+  e.append(...children);
+  return e;
+}
+
+// daysLeft returns days until the API date, interpreted as UTC.
+function daysLeft(date) {
+  const t = Date.parse(date.replace(" ", "T") + "Z");
+  if (isNaN(t)) {
+    return "";
+  }
+  return Math.floor((t - Date.now()) / 86400000);
+}
+
+async function loadServers() {
+  const error = document.getElementById("error");
+  try {
+    const servers = await api("servers");
+    const rows = servers.map((s) => {
+      const days = s.expires ? daysLeft(s.expires) : "";
+      const row = el("tr", { className: s.configured ? "" : "unconfigured" },
+        el("td", {}, s.server_id),
+        el("td", {}, s.server_name || ""),
+        el("td", {}, s.expires || ""),
+        el("td", { className: days !== "" && days < 14 ? "expiring" : "" }, String(days)),
+        el("td", {}, s.param_ram ? s.param_ram + " MB" : ""),
+        el("td", {}, s.param_disk ? s.param_disk + " GB" : ""));
+      if (s.server_id === state.selected) {
+        row.classList.add("selected");
+      }
+      row.onclick = () => select(s);
+      return row;
+    });
+    document.getElementById("server-list").replaceChildren(...rows);
+    error.hidden = true;
+  } catch (e) {
+    error.textContent = e.message;
+    error.hidden = false;
+  }
+}
+
+async function select(server) {
+  state.selected = server.server_id;
+  state.configured = server.configured;
+  document.getElementById("details").hidden = false;
+  document.getElementById("details-title").textContent = server.server_id + (server.server_name ? " (" + server.server_name + ")" : "");
+  document.getElementById("status").textContent = server.configured ? "" : "Not configured. Add a profile with the API key of this server to see its stats and logs.";
+  document.getElementById("actions").hidden = state.config.read_only || !server.configured;
+  document.getElementById("stats").replaceChildren();
+  document.getElementById("logs").replaceChildren();
+  document.getElementById("history").hidden = true;
+  await loadServers();
+  if (server.configured) {
+    await loadDetails(server.server_id);
+  }
+}
+
+function gauge(label, ratio, text) {
+  const pct = Math.round(100 * Math.min(Math.max(ratio, 0), 1));
+  return el("div", { className: "gauge" },
+    el("span", {}, label),
+    el("div", { className: "bar" }, el("div", { style: "width: " + pct + "%" })),
+    el("span", {}, pct + "% " + text));
+}
+
+function chart(title, values) {
+  const width = 300, height = 80;
+  const max = Math.max(...values, 1e-9);
+  const step = values.length > 1 ? width / (values.length - 1) : 0;
+  const points = values.map((v, i) => (i * step).toFixed(1) + "," + (height - (v / max) * (height - 4) - 2).toFixed(1)).join(" ");
+  const svg = document.createElementNS("http://www.w3.org/2000/svg", "svg");
+  svg.setAttribute("width", width);
+  svg.setAttribute("height", height);
+  const line = document.createElementNS("http://www.w3.org/2000/svg", "polyline");
+  line.setAttribute("points", points);
+  svg.append(line);
+  const last = values.length ? values[values.length - 1].toFixed(2) : "n/a";
+  return el("div", { className: "chart" }, el("div", {}, title + ": " + last), svg);
+}
+
+async function loadDetails(id) {
+  const status = document.getElementById("status");
+  try {
+    const [stats, logs] = await Promise.all([api("servers/" + id + "/stats"), api("servers/" + id + "/logs")]);
+    if (id !== state.selected) {
+      return;
+    }
+    const mem = stats.memory;
+    const disk = stats.disk_space;
+    const up = stats.uptime;
+    document.getElementById("stats").replaceChildren(
+      gauge("Memory", mem.used / mem.total, mem.used + "/" + mem.total + " MB"),
+      gauge("Disk", parseFloat(disk.usage) / 100, disk.used + "/" + disk.size),
+      el("p", {}, "Load " + [up.load_average_1_min, up.load_average_5_min, up.load_average_15_min].map((l) => l.toFixed(2)).join(" ") +
+        ", up " + Math.floor(up.days_up / 86400e9) + " days, " + (stats.processes || []).length + " processes"));
+    document.getElementById("logs").replaceChildren(...logs.map((l) =>
+      el("tr", { title: l.output },
+        el("td", {}, l.id),
+        el("td", {}, l.when_created),
+        el("td", {}, l.result.task),
+        el("td", { className: l.result.done && !l.result.success ? "failed" : "" },
+          l.result.done ? (l.result.success ? "succeeded: " : "failed: ") + l.result.message : "pending"))));
+  } catch (e) {
+    status.textContent = e.message;
+  }
+  if (state.config.history) {
+    loadHistory(id);
+  }
+}
+
+async function loadHistory(id) {
+  try {
+    const snaps = await api("servers/" + id + "/history?since=24h");
+    if (id !== state.selected || snaps.length === 0) {
+      return;
+    }
+    document.getElementById("charts").replaceChildren(
+      chart("Memory used (MB)", snaps.map((s) => s.memory_used)),
+      chart("Disk used (%)", snaps.map((s) => (s.disk_size ? (100 * s.disk_used) / s.disk_size : 0))),
+      chart("Load (1 min)", snaps.map((s) => s.load1)));
+    document.getElementById("history").hidden = false;
+  } catch (e) {
+    document.getElementById("status").textContent = e.message;
+  }
+}
+
+async function runTask(task) {
+  const id = state.selected;
+  if (!confirm(task[0].toUpperCase() + task.slice(1) + " " + id + "?")) {
+    return;
+  }
+  const status = document.getElementById("status");
+  try {
+    const res = await api("servers/" + id + "/" + task, { method: "POST" });
+    status.textContent = task + " scheduled: " + res.msg;
+    await loadDetails(id);
+  } catch (e) {
+    status.textContent = task + " failed: " + e.message;
+  }
+}
+
+async function main() {
+  state.config = await api("config");
+  document.getElementById("mode").textContent = state.config.read_only ? "read-only" : "";
+  document.getElementById("restart").onclick = () => runTask("restart");
+  document.getElementById("boost").onclick = () => runTask("boost");
+  await loadServers();
+  setInterval(() => {
+    loadServers();
+    if (state.selected && state.configured) {
+      loadDetails(state.selected);
+    }
+  }, 60000);
+}
+
+main();
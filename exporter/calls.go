@@ -0,0 +1,62 @@
+package exporter
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/qba73/mikrus"
+)
+
+// CallMetrics counts calls of the Mikrus API, their errors and
+// latency per verb. *CallMetrics implements mikrus.Metrics, so it
+// can be set as the Metrics of clients to export their calls.
+type CallMetrics struct {
+	mu    sync.Mutex
+	verbs map[string]*verbMetrics
+}
+
+type verbMetrics struct {
+	calls    map[int]int // by response status
+	errors   int
+	duration float64 // in seconds
+}
+
+// ObserveCall implements mikrus.Metrics.
+func (m *CallMetrics) ObserveCall(c mikrus.Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.verbs == nil {
+		m.verbs = map[string]*verbMetrics{}
+	}
+	v, ok := m.verbs[c.Verb]
+	if !ok {
+		v = &verbMetrics{calls: map[int]int{}}
+		m.verbs[c.Verb] = v
+	}
+	v.calls[c.Status]++
+	if c.Err != nil {
+		v.errors++
+	}
+	v.duration += c.Duration.Seconds()
+}
+
+func (m *CallMetrics) collect(set *metricSet) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, verb := range slices.Sorted(maps.Keys(m.verbs)) {
+		v := m.verbs[verb]
+		labels := fmt.Sprintf("verb=%q", verb)
+		for _, status := range slices.Sorted(maps.Keys(v.calls)) {
+			code := strconv.Itoa(status)
+			if status == 0 {
+				code = "none"
+			}
+			set.addCounter("mikrus_api_requests_total", "Number of Mikrus API requests by verb and response status.", fmt.Sprintf("%s,code=%q", labels, code), float64(v.calls[status]))
+		}
+		set.addCounter("mikrus_api_request_errors_total", "Number of failed Mikrus API requests by verb.", labels, float64(v.errors))
+		set.addCounter("mikrus_api_request_duration_seconds_total", "Time spent in Mikrus API requests by verb in seconds.", labels, v.duration)
+	}
+}
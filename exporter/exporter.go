@@ -0,0 +1,262 @@
+// Package exporter exposes Mikrus server statistics as Prometheus metrics.
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Source provides information about a single Mikrus server.
+// *mikrus.Client implements Source.
+type Source interface {
+	Info() (mikrus.Server, error)
+	Stats() (mikrus.Stats, error)
+}
+
+// Target represents a Mikrus server exported by the Exporter.
+type Target struct {
+	ServerID string
+	Source   Source
+}
+
+// Exporter is an http.Handler serving metrics of Mikrus servers
+// in the Prometheus text exposition format.
+//
+// Metrics are cached for the TTL, so Prometheus scrapes do not result
+// in calls to the Mikrus API more often than once per TTL.
+type Exporter struct {
+	targets []Target
+	ttl     time.Duration
+
+	// ErrorLog specifies an optional logger for errors returned
+	// by the Mikrus API. If nil, errors are not logged.
+	ErrorLog *log.Logger
+
+	// CacheStats optionally reports statistics of the cache of
+	// Mikrus API responses, exported as mikrus_api_cache_* counters.
+	CacheStats func() mikrus.CacheStats
+
+	// Calls optionally counts calls of the Mikrus API, exported as
+	// mikrus_api_request* metrics. Set it as the Metrics of the
+	// clients of the targets.
+	Calls *CallMetrics
+
+	// refreshMu serializes refreshes, mu guards the cached metrics,
+	// so scrapes don't wait for API calls of a refresh in progress.
+	refreshMu sync.Mutex
+	mu        sync.Mutex
+	updated   time.Time
+	metrics   []byte
+}
+
+// New creates an exporter for the targets caching metrics for the ttl.
+// The ttl must be positive.
+func New(targets []Target, ttl time.Duration) (*Exporter, error) {
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+	return &Exporter{
+		targets: targets,
+		ttl:     ttl,
+	}, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(e.Metrics())
+}
+
+// Metrics returns cached metrics, collecting them first when
+// the cache is empty or older than the TTL. While another refresh
+// is in progress, stale metrics are returned rather than waiting
+// for it.
+func (e *Exporter) Metrics() []byte {
+	metrics, fresh := e.cached()
+	switch {
+	case fresh:
+		return metrics
+	case metrics == nil:
+		e.refreshMu.Lock()
+	case !e.refreshMu.TryLock():
+		return metrics
+	}
+	defer e.refreshMu.Unlock()
+	if metrics, fresh := e.cached(); fresh {
+		return metrics
+	}
+	return e.refresh()
+}
+
+// cached returns the cached metrics and whether they are younger
+// than the TTL.
+func (e *Exporter) cached() ([]byte, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.metrics, e.metrics != nil && time.Since(e.updated) < e.ttl
+}
+
+// Run refreshes metrics every TTL until the context is cancelled.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl)
+	defer ticker.Stop()
+	for {
+		e.refreshMu.Lock()
+		e.refresh()
+		e.refreshMu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh collects metrics of all targets and caches them. It must
+// be called with e.refreshMu held.
+func (e *Exporter) refresh() []byte {
+	var set metricSet
+	for _, t := range e.targets {
+		e.collect(&set, t)
+	}
+	if e.CacheStats != nil {
+		cs := e.CacheStats()
+		set.addCounter("mikrus_api_cache_hits_total", "Number of API responses served from the cache.", "", float64(cs.Hits))
+		set.addCounter("mikrus_api_cache_misses_total", "Number of API responses not found in the cache.", "", float64(cs.Misses))
+		set.addCounter("mikrus_api_cache_invalidations_total", "Number of cache invalidations after mutating API calls.", "", float64(cs.Invalidations))
+	}
+	if e.Calls != nil {
+		e.Calls.collect(&set)
+	}
+	var buf bytes.Buffer
+	set.write(&buf)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.metrics = buf.Bytes()
+	e.updated = time.Now()
+	return e.metrics
+}
+
+func (e *Exporter) collect(set *metricSet, t Target) {
+	labels := fmt.Sprintf("server_id=%q", t.ServerID)
+	up := 1.0
+	defer func() {
+		set.add("mikrus_up", "Whether the last query of the Mikrus API was successful.", labels, up)
+	}()
+
+	server, err := t.Source.Info()
+	if err != nil {
+		e.logf("%s: fetching server info: %v", t.ServerID, err)
+		up = 0
+		return
+	}
+	if expires, err := server.ExpiresAt(); err == nil {
+		days := expires.Sub(time.Now()).Hours() / 24
+		set.add("mikrus_expiry_days", "Number of days until the server expires.", labels, days)
+	}
+
+	stats, err := t.Source.Stats()
+	if err != nil {
+		e.logf("%s: fetching server stats: %v", t.ServerID, err)
+		up = 0
+		return
+	}
+	const mb = 1 << 20
+	m := stats.Memory
+	set.add("mikrus_memory_total_bytes", "Total memory in bytes.", labels, float64(m.Total)*mb)
+	set.add("mikrus_memory_used_bytes", "Used memory in bytes.", labels, float64(m.Used)*mb)
+	set.add("mikrus_memory_free_bytes", "Free memory in bytes.", labels, float64(m.Free)*mb)
+	set.add("mikrus_memory_cache_bytes", "Memory used by buffers and cache in bytes.", labels, float64(m.Cache)*mb)
+	set.add("mikrus_memory_available_bytes", "Memory available for new processes in bytes.", labels, float64(m.Available)*mb)
+	set.add("mikrus_swap_total_bytes", "Total swap in bytes.", labels, float64(m.SwapTotal)*mb)
+	set.add("mikrus_swap_used_bytes", "Used swap in bytes.", labels, float64(m.SwapUsed)*mb)
+
+	d := stats.DiskSpace
+	if size, err := d.SizeBytes(); err == nil {
+		set.add("mikrus_disk_size_bytes", "Root filesystem size in bytes.", labels, float64(size))
+	}
+	if used, err := d.UsedBytes(); err == nil {
+		set.add("mikrus_disk_used_bytes", "Used space on the root filesystem in bytes.", labels, float64(used))
+	}
+	if avail, err := d.AvailableBytes(); err == nil {
+		set.add("mikrus_disk_available_bytes", "Available space on the root filesystem in bytes.", labels, float64(avail))
+	}
+
+	u := stats.Uptime
+	set.add("mikrus_uptime_seconds", "Server uptime in seconds.", labels, u.Uptime.Seconds())
+	set.add("mikrus_load1", "1 minute load average.", labels, u.CPUload1min)
+	set.add("mikrus_load5", "5 minutes load average.", labels, u.CPUload5min)
+	set.add("mikrus_load15", "15 minutes load average.", labels, u.CPUload15min)
+	set.add("mikrus_users", "Number of logged in users.", labels, float64(u.Users))
+
+	states := map[string]int{}
+	for _, p := range stats.Processes {
+		states[p.State[:min(len(p.State), 1)]]++
+	}
+	for _, state := range slices.Sorted(maps.Keys(states)) {
+		set.add("mikrus_processes", "Number of processes by state.", fmt.Sprintf("%s,state=%q", labels, state), float64(states[state]))
+	}
+}
+
+func (e *Exporter) logf(format string, args ...any) {
+	if e.ErrorLog != nil {
+		e.ErrorLog.Printf(format, args...)
+	}
+}
+
+// metricSet holds samples grouped by metric name, in the order
+// the metrics were first added.
+type metricSet struct {
+	names   []string
+	help    map[string]string
+	types   map[string]string
+	samples map[string][]string
+}
+
+func (s *metricSet) add(name, help, labels string, value float64) {
+	s.addSample("gauge", name, help, labels, value)
+}
+
+func (s *metricSet) addCounter(name, help, labels string, value float64) {
+	s.addSample("counter", name, help, labels, value)
+}
+
+func (s *metricSet) addSample(kind, name, help, labels string, value float64) {
+	if s.help == nil {
+		s.help = map[string]string{}
+		s.types = map[string]string{}
+		s.samples = map[string][]string{}
+	}
+	if _, ok := s.help[name]; !ok {
+		s.names = append(s.names, name)
+		s.help[name] = help
+		s.types[name] = kind
+	}
+	sample := name
+	if labels != "" {
+		sample += "{" + labels + "}"
+	}
+	sample += " " + strconv.FormatFloat(value, 'g', -1, 64)
+	s.samples[name] = append(s.samples[name], sample)
+}
+
+func (s *metricSet) write(w io.Writer) {
+	for _, name := range s.names {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, s.help[name])
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, s.types[name])
+		fmt.Fprintln(w, strings.Join(s.samples[name], "\n"))
+	}
+}
@@ -0,0 +1,212 @@
+package exporter_test
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/exporter"
+)
+
+type fakeSource struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (f *fakeSource) Info() (mikrus.Server, error) {
+	f.calls.Add(1)
+	if f.err != nil {
+		return mikrus.Server{}, f.err
+	}
+	return mikrus.Server{
+		ServerID: "j230",
+		Expires:  time.Now().Add(30*24*time.Hour + time.Hour).In(mikrus.Location).Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+func (f *fakeSource) Stats() (mikrus.Stats, error) {
+	return mikrus.Stats{
+		Memory: mikrus.Memory{Total: 1024, Used: 43, SwapTotal: 512, SwapUsed: 0},
+		DiskSpace: mikrus.DiskSpace{
+			Size:      "10G",
+			Used:      "2.5G",
+			Available: "7.5G",
+			Usage:     "25%",
+		},
+		Uptime: mikrus.Uptime{
+			Uptime:       2 * time.Hour,
+			CPUload1min:  0.1,
+			CPUload5min:  0.25,
+			CPUload15min: 0.5,
+		},
+		Processes: mikrus.Processes{{State: "Ss"}, {State: "S"}, {State: "R+"}},
+	}, nil
+}
+
+func newExporter(t *testing.T, targets []exporter.Target, ttl time.Duration) *exporter.Exporter {
+	t.Helper()
+	e, err := exporter.New(targets, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestNew_RejectsNonPositiveTTL(t *testing.T) {
+	t.Parallel()
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		if _, err := exporter.New(nil, ttl); err == nil {
+			t.Errorf("want error for ttl %s", ttl)
+		}
+	}
+}
+
+// slowSource blocks calls after the first until release is closed,
+// signalling on blocked when a call blocks.
+type slowSource struct {
+	fakeSource
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (s *slowSource) Info() (mikrus.Server, error) {
+	if s.calls.Load() > 0 {
+		s.blocked <- struct{}{}
+		<-s.release
+	}
+	return s.fakeSource.Info()
+}
+
+func TestExporter_ServesStaleMetricsDuringRefresh(t *testing.T) {
+	t.Parallel()
+	src := &slowSource{blocked: make(chan struct{}), release: make(chan struct{})}
+	defer close(src.release)
+	e := newExporter(t, []exporter.Target{{ServerID: "j230", Source: src}}, time.Nanosecond)
+	stale := e.Metrics()
+	go e.Metrics()
+	<-src.blocked
+	done := make(chan []byte)
+	go func() { done <- e.Metrics() }()
+	select {
+	case got := <-done:
+		if string(got) != string(stale) {
+			t.Errorf("want stale metrics, got:\n%s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("scrape waited for refresh in progress")
+	}
+}
+
+func TestExporter_ServesMetricsInPrometheusFormat(t *testing.T) {
+	t.Parallel()
+	e := newExporter(t, []exporter.Target{{ServerID: "j230", Source: &fakeSource{}}}, time.Minute)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(body)
+	for _, want := range []string{
+		"# TYPE mikrus_memory_total_bytes gauge\n",
+		`mikrus_memory_total_bytes{server_id="j230"} 1.073741824e+09`,
+		`mikrus_swap_total_bytes{server_id="j230"} 5.36870912e+08`,
+		`mikrus_disk_used_bytes{server_id="j230"} 2.68435456e+09`,
+		`mikrus_load15{server_id="j230"} 0.5`,
+		`mikrus_uptime_seconds{server_id="j230"} 7200`,
+		`mikrus_processes{server_id="j230",state="S"} 2`,
+		`mikrus_processes{server_id="j230",state="R"} 1`,
+		`mikrus_expiry_days{server_id="j230"} 30.`,
+		`mikrus_up{server_id="j230"} 1`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestExporter_ReportsFailedServersAsDown(t *testing.T) {
+	t.Parallel()
+	e := newExporter(t, []exporter.Target{
+		{ServerID: "a135", Source: &fakeSource{err: errors.New("boom")}},
+		{ServerID: "j230", Source: &fakeSource{}},
+	}, time.Minute)
+	got := string(e.Metrics())
+	for _, want := range []string{`mikrus_up{server_id="a135"} 0`, `mikrus_up{server_id="j230"} 1`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, `mikrus_load1{server_id="a135"}`) {
+		t.Errorf("want no stats for failed server, got:\n%s", got)
+	}
+}
+
+func TestExporter_CachesMetricsForTTL(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{}
+	e := newExporter(t, []exporter.Target{{ServerID: "j230", Source: src}}, time.Hour)
+	for range 3 {
+		e.Metrics()
+	}
+	if got := src.calls.Load(); got != 1 {
+		t.Errorf("want 1 API call within TTL, got %d", got)
+	}
+}
+
+func TestExporter_RefreshesExpiredMetrics(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{}
+	e := newExporter(t, []exporter.Target{{ServerID: "j230", Source: src}}, time.Nanosecond)
+	for range 3 {
+		e.Metrics()
+	}
+	if got := src.calls.Load(); got != 3 {
+		t.Errorf("want 3 API calls with expired cache, got %d", got)
+	}
+}
+
+func TestExporter_ExportsCacheStats(t *testing.T) {
+	t.Parallel()
+	e := newExporter(t, []exporter.Target{{ServerID: "j230", Source: &fakeSource{}}}, time.Minute)
+	e.CacheStats = func() mikrus.CacheStats {
+		return mikrus.CacheStats{Hits: 7, Misses: 3, Invalidations: 1}
+	}
+	got := string(e.Metrics())
+	for _, want := range []string{
+		"# TYPE mikrus_api_cache_hits_total counter\nmikrus_api_cache_hits_total 7\n",
+		"mikrus_api_cache_misses_total 3\n",
+		"mikrus_api_cache_invalidations_total 1\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestExporter_ExportsAPICalls(t *testing.T) {
+	t.Parallel()
+	e := newExporter(t, []exporter.Target{{ServerID: "j230", Source: &fakeSource{}}}, time.Minute)
+	e.Calls = &exporter.CallMetrics{}
+	e.Calls.ObserveCall(mikrus.Call{Verb: "info", Status: 200, Duration: time.Second})
+	e.Calls.ObserveCall(mikrus.Call{Verb: "info", Status: 500, Duration: time.Second / 2, Err: errors.New("boom")})
+	e.Calls.ObserveCall(mikrus.Call{Verb: "stats", Err: errors.New("timeout")})
+	got := string(e.Metrics())
+	for _, want := range []string{
+		"# TYPE mikrus_api_requests_total counter\n" +
+			"mikrus_api_requests_total{verb=\"info\",code=\"200\"} 1\n" +
+			"mikrus_api_requests_total{verb=\"info\",code=\"500\"} 1\n" +
+			"mikrus_api_requests_total{verb=\"stats\",code=\"none\"} 1\n",
+		"mikrus_api_request_errors_total{verb=\"info\"} 1\n",
+		"mikrus_api_request_duration_seconds_total{verb=\"info\"} 1.5\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want metrics to contain %q, got:\n%s", want, got)
+		}
+	}
+}
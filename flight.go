@@ -0,0 +1,43 @@
+package mikrus
+
+import "sync"
+
+// flightGroup coalesces concurrent calls with the same key, so
+// callers arriving while a call is in flight share its result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// do calls fn, unless a call with the key is in flight, in which case
+// it waits for that call and returns its result. The returned body
+// is shared by callers and must not be modified.
+func (g *flightGroup) do(key string, fn func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-f.done
+		return f.body, f.err
+	}
+	if g.calls == nil {
+		g.calls = map[string]*flight{}
+	}
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.body, f.err = fn()
+	return f.body, f.err
+}
@@ -0,0 +1,148 @@
+package mikrus_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+// concurrently calls fn from n goroutines started at the same time
+// and waits for them to finish.
+func concurrently(n int, fn func()) {
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			fn()
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func slowScenario() mikrustest.Scenario {
+	sc := mikrustest.DefaultScenario()
+	sc.Latency = 200 * time.Millisecond
+	sc.Servers = append(sc.Servers, mikrustest.ServerConfig{ID: "a101", APIKey: "otherKey", RAM: 2048, Disk: 20})
+	return sc
+}
+
+func TestClient_CoalescesConcurrentIdenticalReads(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(slowScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	concurrently(10, func() {
+		// Copies of the client share in-flight calls.
+		c := c
+		stats, err := c.Stats()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if stats.Memory.Total != 1024 {
+			t.Errorf("want total memory 1024, got %d", stats.Memory.Total)
+		}
+		if _, err := c.Info(); err != nil {
+			t.Error(err)
+		}
+	})
+	if got := ts.Handler.Calls("stats"); got != 1 {
+		t.Errorf("want 1 stats call, got %d", got)
+	}
+	if got := ts.Handler.Calls("info"); got != 1 {
+		t.Errorf("want 1 info call, got %d", got)
+	}
+}
+
+func TestClient_DoesNotCoalesceCallsOfDifferentServers(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(slowScenario())
+	defer ts.Close()
+
+	j230, a101 := ts.Client("j230"), ts.Client("a101")
+	var mu sync.Mutex
+	totals := map[int]int{}
+	concurrently(10, func() {
+		for _, c := range []mikrus.Client{j230, a101} {
+			stats, err := c.Stats()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			totals[stats.Memory.Total]++
+			mu.Unlock()
+		}
+	})
+	if totals[1024] != 10 || totals[2048] != 10 {
+		t.Errorf("want stats of both servers, got %v", totals)
+	}
+	if got := ts.Handler.Calls("stats"); got != 2 {
+		t.Errorf("want 2 stats calls, got %d", got)
+	}
+}
+
+func TestClient_DoesNotCoalesceMutatingCalls(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(slowScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	concurrently(3, func() {
+		if _, err := c.Restart(); err != nil {
+			t.Error(err)
+		}
+	})
+	if got := ts.Handler.Calls("restart"); got != 3 {
+		t.Errorf("want 3 restart calls, got %d", got)
+	}
+}
+
+func TestClient_SharesErrorsOfCoalescedCalls(t *testing.T) {
+	t.Parallel()
+	sc := slowScenario()
+	sc.Errors = []mikrustest.ErrorRule{{Verb: "info", Status: http.StatusInternalServerError, Body: "boom"}}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	concurrently(5, func() {
+		if _, err := c.Info(); err == nil {
+			t.Error("want error, got nil")
+		}
+	})
+	if got := ts.Handler.Calls("info"); got != 1 {
+		t.Errorf("want 1 info call, got %d", got)
+	}
+}
+
+func TestClient_IsSafeForConcurrentUseWithCache(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	c.Cache = mikrus.NewMemoryCache()
+	concurrently(20, func() {
+		for range 5 {
+			if _, err := c.Info(); err != nil {
+				t.Error(err)
+			}
+			if _, err := c.Logs(); err != nil {
+				t.Error(err)
+			}
+			if _, err := c.Boost(); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,310 @@
+// Package history stores snapshots of Mikrus server statistics
+// in local append-only files and queries them over time ranges.
+//
+// Every server has its own file with one JSON encoded snapshot per
+// line. Old snapshots are downsampled and eventually removed according
+// to the Retention policy when the store is compacted.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Snapshot represents statistics of a server at a point in time.
+// Memory values are in megabytes, disk values in bytes.
+type Snapshot struct {
+	Time            time.Time     `json:"time"`
+	MemoryTotal     float64       `json:"memory_total"`
+	MemoryUsed      float64       `json:"memory_used"`
+	MemoryAvailable float64       `json:"memory_available"`
+	SwapTotal       float64       `json:"swap_total"`
+	SwapUsed        float64       `json:"swap_used"`
+	DiskSize        float64       `json:"disk_size"`
+	DiskUsed        float64       `json:"disk_used"`
+	Load1           float64       `json:"load1"`
+	Load5           float64       `json:"load5"`
+	Load15          float64       `json:"load15"`
+	Uptime          time.Duration `json:"uptime"`
+	Processes       float64       `json:"processes"`
+
+	// Samples is the number of collected snapshots averaged into
+	// a downsampled snapshot. Zero means a single snapshot.
+	Samples int `json:"samples,omitempty"`
+}
+
+// NewSnapshot creates a snapshot of the stats taken at time t.
+// Disk sizes that cannot be parsed are recorded as zero.
+func NewSnapshot(t time.Time, s mikrus.Stats) Snapshot {
+	size, _ := s.DiskSpace.SizeBytes()
+	used, _ := s.DiskSpace.UsedBytes()
+	return Snapshot{
+		Time:            t.UTC(),
+		MemoryTotal:     float64(s.Memory.Total),
+		MemoryUsed:      float64(s.Memory.Used),
+		MemoryAvailable: float64(s.Memory.Available),
+		SwapTotal:       float64(s.Memory.SwapTotal),
+		SwapUsed:        float64(s.Memory.SwapUsed),
+		DiskSize:        float64(size),
+		DiskUsed:        float64(used),
+		Load1:           s.Uptime.CPUload1min,
+		Load5:           s.Uptime.CPUload5min,
+		Load15:          s.Uptime.CPUload15min,
+		Uptime:          s.Uptime.Uptime,
+		Processes:       float64(len(s.Processes)),
+	}
+}
+
+// Retention describes how long snapshots are kept.
+type Retention struct {
+	// Raw is how long snapshots are kept as collected.
+	Raw time.Duration
+
+	// Resolution is the interval older snapshots are downsampled to.
+	Resolution time.Duration
+
+	// MaxAge is how long downsampled snapshots are kept.
+	// Zero means they are kept forever.
+	MaxAge time.Duration
+}
+
+// DefaultRetention keeps snapshots for two days, and hourly averages
+// of older snapshots for 90 days.
+var DefaultRetention = Retention{
+	Raw:        48 * time.Hour,
+	Resolution: time.Hour,
+	MaxAge:     90 * 24 * time.Hour,
+}
+
+// Store is a collection of snapshot files in a directory.
+// It is safe for concurrent use within a single process.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open opens the store in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(serverID string) (string, error) {
+	if serverID == "" || strings.ContainsAny(serverID, `/\.`) {
+		return "", fmt.Errorf("invalid server ID %q", serverID)
+	}
+	return filepath.Join(s.dir, serverID+".jsonl"), nil
+}
+
+// Append adds the snapshot to the history of the server.
+func (s *Store) Append(serverID string, snap Snapshot) error {
+	path, err := s.path(serverID)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Query returns snapshots of the server taken in the time range
+// [from, to], oldest first. Zero from or to leave the range open.
+func (s *Store) Query(serverID string, from, to time.Time) ([]Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.read(serverID)
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, snap := range all {
+		if !from.IsZero() && snap.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && snap.Time.After(to) {
+			continue
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// Servers returns IDs of servers with recorded history.
+func (s *Store) Servers() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, f := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(f), ".jsonl"))
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// Compact applies the retention policy to the history of the server.
+// Snapshots older than r.Raw are replaced with their averages over
+// r.Resolution intervals, and snapshots older than r.MaxAge are removed.
+func (s *Store) Compact(serverID string, r Retention, now time.Time) error {
+	path, err := s.path(serverID)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all, err := s.read(serverID)
+	if err != nil {
+		return err
+	}
+	compacted := compact(all, r, now)
+
+	tmp, err := os.CreateTemp(s.dir, serverID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, snap := range compacted {
+		if err := enc.Encode(snap); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func compact(snaps []Snapshot, r Retention, now time.Time) []Snapshot {
+	rawFrom := now.Add(-r.Raw)
+	var result []Snapshot
+	var bucket []Snapshot
+	flush := func() {
+		if len(bucket) > 0 {
+			result = append(result, average(bucket, r.Resolution))
+			bucket = nil
+		}
+	}
+	for _, snap := range snaps {
+		if r.MaxAge > 0 && snap.Time.Before(now.Add(-r.MaxAge)) {
+			continue
+		}
+		if !snap.Time.Before(rawFrom) || r.Resolution <= 0 {
+			flush()
+			result = append(result, snap)
+			continue
+		}
+		if len(bucket) > 0 && !bucket[0].Time.Truncate(r.Resolution).Equal(snap.Time.Truncate(r.Resolution)) {
+			flush()
+		}
+		bucket = append(bucket, snap)
+	}
+	flush()
+	return result
+}
+
+// average returns a snapshot with values averaged over the snapshots,
+// timestamped with the start of their resolution interval. Snapshots
+// downsampled before are weighted by the number of their samples, so
+// compacting a bucket again doesn't change its averages.
+func average(snaps []Snapshot, resolution time.Duration) Snapshot {
+	var avg Snapshot
+	var uptime time.Duration
+	for _, s := range snaps {
+		w := float64(max(s.Samples, 1))
+		avg.MemoryTotal += s.MemoryTotal * w
+		avg.MemoryUsed += s.MemoryUsed * w
+		avg.MemoryAvailable += s.MemoryAvailable * w
+		avg.SwapTotal += s.SwapTotal * w
+		avg.SwapUsed += s.SwapUsed * w
+		avg.DiskSize += s.DiskSize * w
+		avg.DiskUsed += s.DiskUsed * w
+		avg.Load1 += s.Load1 * w
+		avg.Load5 += s.Load5 * w
+		avg.Load15 += s.Load15 * w
+		avg.Processes += s.Processes * w
+		avg.Samples += max(s.Samples, 1)
+		uptime = max(uptime, s.Uptime)
+	}
+	n := float64(avg.Samples)
+	avg.MemoryTotal /= n
+	avg.MemoryUsed /= n
+	avg.MemoryAvailable /= n
+	avg.SwapTotal /= n
+	avg.SwapUsed /= n
+	avg.DiskSize /= n
+	avg.DiskUsed /= n
+	avg.Load1 /= n
+	avg.Load5 /= n
+	avg.Load15 /= n
+	avg.Processes /= n
+	avg.Uptime = uptime
+	avg.Time = snaps[0].Time.Truncate(resolution)
+	return avg
+}
+
+// read returns all snapshots of the server. It must be called
+// with s.mu held.
+func (s *Store) read(serverID string) ([]Snapshot, error) {
+	path, err := s.path(serverID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var snaps []Snapshot
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		snaps = append(snaps, snap)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortStableFunc(snaps, func(a, b Snapshot) int { return a.Time.Compare(b.Time) })
+	return snaps, nil
+}
@@ -0,0 +1,171 @@
+package history_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/history"
+)
+
+var start = time.Date(2024, time.June, 5, 10, 0, 0, 0, time.UTC)
+
+func TestNewSnapshot_SummarisesStats(t *testing.T) {
+	t.Parallel()
+	stats := mikrus.Stats{
+		Memory:    mikrus.Memory{Total: 1024, Used: 43, Available: 980, SwapUsed: 12},
+		DiskSpace: mikrus.DiskSpace{Size: "10G", Used: "2.5G"},
+		Uptime:    mikrus.Uptime{Uptime: time.Hour, CPUload1min: 0.5, CPUload5min: 0.25, CPUload15min: 0.1},
+		Processes: mikrus.Processes{{PID: 1}, {PID: 2}},
+	}
+	want := history.Snapshot{
+		Time:            start,
+		MemoryTotal:     1024,
+		MemoryUsed:      43,
+		MemoryAvailable: 980,
+		SwapUsed:        12,
+		DiskSize:        10 << 30,
+		DiskUsed:        2.5 * (1 << 30),
+		Load1:           0.5,
+		Load5:           0.25,
+		Load15:          0.1,
+		Uptime:          time.Hour,
+		Processes:       2,
+	}
+	got := history.NewSnapshot(start, stats)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStore_QueriesSnapshotsInTimeRange(t *testing.T) {
+	t.Parallel()
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		snap := history.Snapshot{Time: start.Add(time.Duration(i) * time.Hour), MemoryUsed: float64(i)}
+		if err := store.Append("j230", snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := store.Query("j230", start.Add(time.Hour), start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{1, 2, 3}
+	m, err := history.LookupMetric("memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(want, m.Values(got)) {
+		t.Error(cmp.Diff(want, m.Values(got)))
+	}
+	servers, err := store.Servers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal([]string{"j230"}, servers) {
+		t.Errorf("want servers [j230], got %v", servers)
+	}
+}
+
+func TestStore_QueryReturnsNothingForUnknownServer(t *testing.T) {
+	t.Parallel()
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Query("a135", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no snapshots, got %d", len(got))
+	}
+}
+
+func TestStore_CompactDownsamplesAndRemovesOldSnapshots(t *testing.T) {
+	t.Parallel()
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := start.Add(72 * time.Hour)
+	times := []time.Time{
+		start.Add(-time.Hour),       // older than MaxAge, removed
+		start.Add(10 * time.Minute), // averaged with the next one
+		start.Add(40 * time.Minute), //
+		start.Add(time.Hour),        // alone in its hour
+		now.Add(-30 * time.Minute),  // raw
+		now.Add(-20 * time.Minute),  // raw
+	}
+	for i, ts := range times {
+		if err := store.Append("j230", history.Snapshot{Time: ts, Load1: float64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := history.Retention{Raw: 24 * time.Hour, Resolution: time.Hour, MaxAge: 72 * time.Hour}
+	if err := store.Compact("j230", r, now); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Query("j230", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotTimes []time.Time
+	var gotLoad []float64
+	for _, s := range got {
+		gotTimes = append(gotTimes, s.Time)
+		gotLoad = append(gotLoad, s.Load1)
+	}
+	wantTimes := []time.Time{start, start.Add(time.Hour), times[4], times[5]}
+	if !cmp.Equal(wantTimes, gotTimes) {
+		t.Error(cmp.Diff(wantTimes, gotTimes))
+	}
+	wantLoad := []float64{1.5, 3, 4, 5}
+	if !cmp.Equal(wantLoad, gotLoad) {
+		t.Error(cmp.Diff(wantLoad, gotLoad))
+	}
+}
+
+func TestStore_CompactKeepsAveragesOfCompactedBuckets(t *testing.T) {
+	t.Parallel()
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := history.Retention{Raw: time.Hour, Resolution: time.Hour}
+	for i, load := range []float64{0, 0, 6} {
+		snap := history.Snapshot{Time: start.Add(time.Duration(10+20*i) * time.Minute), Load1: load}
+		if err := store.Append("j230", snap); err != nil {
+			t.Fatal(err)
+		}
+		// Compact after every snapshot, so the bucket of the hour is
+		// downsampled again with each newly aged snapshot.
+		if err := store.Compact("j230", r, snap.Time.Add(r.Raw+time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := store.Query("j230", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []history.Snapshot{{Time: start, Load1: 2, Samples: 3}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStore_RejectsInvalidServerID(t *testing.T) {
+	t.Parallel()
+	store, err := history.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append("../etc", history.Snapshot{}); err == nil {
+		t.Fatal("want error for invalid server ID, got nil")
+	}
+}
@@ -0,0 +1,166 @@
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metric extracts a single value from a snapshot.
+type Metric struct {
+	Name  string
+	Unit  string
+	Value func(Snapshot) float64
+}
+
+// Metrics lists metrics that can be queried from the history.
+var Metrics = []Metric{
+	{Name: "memory", Unit: "MB", Value: func(s Snapshot) float64 { return s.MemoryUsed }},
+	{Name: "memory_available", Unit: "MB", Value: func(s Snapshot) float64 { return s.MemoryAvailable }},
+	{Name: "swap", Unit: "MB", Value: func(s Snapshot) float64 { return s.SwapUsed }},
+	{Name: "disk", Unit: "GB", Value: func(s Snapshot) float64 { return s.DiskUsed / (1 << 30) }},
+	{Name: "disk_percent", Unit: "%", Value: diskPercent},
+	{Name: "load1", Value: func(s Snapshot) float64 { return s.Load1 }},
+	{Name: "load5", Value: func(s Snapshot) float64 { return s.Load5 }},
+	{Name: "load15", Value: func(s Snapshot) float64 { return s.Load15 }},
+	{Name: "processes", Value: func(s Snapshot) float64 { return s.Processes }},
+}
+
+func diskPercent(s Snapshot) float64 {
+	if s.DiskSize == 0 {
+		return 0
+	}
+	return 100 * s.DiskUsed / s.DiskSize
+}
+
+// LookupMetric returns the metric with the name.
+func LookupMetric(name string) (Metric, error) {
+	for _, m := range Metrics {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	names := make([]string, 0, len(Metrics))
+	for _, m := range Metrics {
+		names = append(names, m.Name)
+	}
+	return Metric{}, fmt.Errorf("unknown metric %q, want one of: %s", name, strings.Join(names, ", "))
+}
+
+// Values returns values of the metric for every snapshot.
+func (m Metric) Values(snaps []Snapshot) []float64 {
+	values := make([]float64, 0, len(snaps))
+	for _, s := range snaps {
+		values = append(values, m.Value(s))
+	}
+	return values
+}
+
+// Summary returns a one line summary of the metric values with
+// their minimum, maximum and last value, and a sparkline at most
+// width characters wide.
+func (m Metric) Summary(snaps []Snapshot, width int) string {
+	if len(snaps) == 0 {
+		return fmt.Sprintf("%s: no data", m.Name)
+	}
+	values := m.Values(snaps)
+	name := m.Name
+	if m.Unit != "" {
+		name += " (" + m.Unit + ")"
+	}
+	return fmt.Sprintf("%s: min %s max %s last %s %s",
+		name, formatValue(slices.Min(values)), formatValue(slices.Max(values)), formatValue(values[len(values)-1]),
+		Sparkline(values, width))
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as a sparkline. When there are more values
+// than width, consecutive values are averaged to fit. Zero width
+// disables averaging.
+func Sparkline(values []float64, width int) string {
+	if width > 0 && len(values) > width {
+		values = resample(values, width)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// resample averages values into n buckets.
+func resample(values []float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := range n {
+		start, end := i*len(values)/n, (i+1)*len(values)/n
+		var sum float64
+		for _, v := range values[start:end] {
+			sum += v
+		}
+		out[i] = sum / float64(end-start)
+	}
+	return out
+}
+
+// CSVWriter writes snapshots of one or more servers as CSV. The header
+// row is written once, before the first snapshots, and every row
+// starts with the ID of the server.
+type CSVWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns a CSVWriter writing to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes the snapshots of the server.
+func (c *CSVWriter) Write(serverID string, snaps []Snapshot) error {
+	if !c.header {
+		header := []string{"server_id", "time"}
+		for _, m := range Metrics {
+			header = append(header, m.Name)
+		}
+		header = append(header, "uptime_seconds")
+		if err := c.w.Write(header); err != nil {
+			return err
+		}
+		c.header = true
+	}
+	for _, s := range snaps {
+		row := []string{serverID, s.Time.Format(time.RFC3339)}
+		for _, m := range Metrics {
+			row = append(row, formatValue(m.Value(s)))
+		}
+		row = append(row, strconv.FormatFloat(s.Uptime.Seconds(), 'f', -1, 64))
+		if err := c.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (c *CSVWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,92 @@
+package history_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus/history"
+)
+
+func TestSparkline_ScalesValuesToBlocks(t *testing.T) {
+	t.Parallel()
+	got := history.Sparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, 0)
+	want := "▁▂▃▄▅▆▇█"
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSparkline_AveragesValuesToFitWidth(t *testing.T) {
+	t.Parallel()
+	got := history.Sparkline([]float64{0, 0, 7, 7}, 2)
+	want := "▁█"
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSparkline_RendersFlatLineForConstantValues(t *testing.T) {
+	t.Parallel()
+	got := history.Sparkline([]float64{3, 3, 3}, 0)
+	want := "▁▁▁"
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestMetric_SummaryReportsMinMaxAndLast(t *testing.T) {
+	t.Parallel()
+	m, err := history.LookupMetric("load1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snaps := []history.Snapshot{{Load1: 0.5}, {Load1: 2}, {Load1: 1.25}}
+	got := m.Summary(snaps, 10)
+	want := "load1: min 0.5 max 2 last 1.25 ▁█▄"
+	if want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestLookupMetric_ErrorsForUnknownMetric(t *testing.T) {
+	t.Parallel()
+	if _, err := history.LookupMetric("bogus"); err == nil {
+		t.Fatal("want error for unknown metric, got nil")
+	}
+}
+
+func TestCSVWriter_WritesHeaderOnceAndServerIDs(t *testing.T) {
+	t.Parallel()
+	snaps := []history.Snapshot{{
+		Time:       time.Date(2024, time.June, 5, 10, 0, 0, 0, time.UTC),
+		MemoryUsed: 43,
+		DiskSize:   10 << 30,
+		DiskUsed:   5 << 30,
+		Load1:      0.5,
+		Uptime:     time.Minute,
+		Processes:  17,
+	}}
+	var b strings.Builder
+	w := history.NewCSVWriter(&b)
+	if err := w.Write("a135", snaps); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write("j230", snaps); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"server_id,time,memory,memory_available,swap,disk,disk_percent,load1,load5,load15,processes,uptime_seconds",
+		"a135,2024-06-05T10:00:00Z,43,0,0,5,50,0.5,0,0,17,60",
+		"j230,2024-06-05T10:00:00Z,43,0,0,5,50,0.5,0,0,17,60",
+		"",
+	}
+	got := strings.Split(b.String(), "\n")
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
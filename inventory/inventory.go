@@ -0,0 +1,248 @@
+// Package inventory builds Ansible inventories of Mikrus servers.
+//
+// Every server is a host in the "mikrus" group with connection
+// variables derived from its ID, and a member of groups by RAM, disk
+// and Pro status, like "ram_1024", "disk_10" and "pro", which carry
+// the respective group variables.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/sshconfig"
+	"go.yaml.in/yaml/v3"
+)
+
+// Group is the group all hosts belong to.
+const Group = "mikrus"
+
+// Vars holds Ansible variables.
+type Vars map[string]any
+
+// Host represents a server in the inventory.
+type Host struct {
+	Name   string
+	Groups []string
+	Vars   Vars
+}
+
+// Inventory represents an Ansible inventory.
+type Inventory struct {
+	Hosts     []Host
+	GroupVars map[string]Vars
+}
+
+// Build returns the inventory of the servers. Details of servers
+// from info, keyed by server ID, are used for Pro status when known.
+// Connection variables are set from the resolver; servers with
+// unknown SSH hosts get only ansible_port, so Ansible connects to
+// the inventory host name.
+func Build(servers []mikrus.ServerShort, info map[string]mikrus.Server, r sshconfig.Resolver) Inventory {
+	inv := Inventory{GroupVars: map[string]Vars{}}
+	for _, s := range servers {
+		vars := Vars{
+			"mikrus_server_id": s.ServerID,
+			"mikrus_expires":   s.Expires,
+		}
+		if s.ServerName != "" {
+			vars["mikrus_server_name"] = s.ServerName
+		}
+		if h, err := r.Resolve(s.ServerID); err == nil {
+			vars["ansible_host"] = h.HostName
+			vars["ansible_port"] = h.Port
+			vars["ansible_user"] = h.User
+			if h.IdentityFile != "" {
+				vars["ansible_ssh_private_key_file"] = h.IdentityFile
+			}
+		} else if port, err := sshconfig.Port(s.ServerID); err == nil {
+			vars["ansible_port"] = port
+		}
+
+		groups := []string{Group}
+		if ram := groupSuffix(s.ParamRam); ram != "" {
+			name := "ram_" + ram
+			groups = append(groups, name)
+			inv.GroupVars[name] = Vars{"mikrus_ram": number(s.ParamRam)}
+		}
+		if disk := groupSuffix(s.ParamDisk); disk != "" {
+			name := "disk_" + disk
+			groups = append(groups, name)
+			inv.GroupVars[name] = Vars{"mikrus_disk": number(s.ParamDisk)}
+		}
+		if details, ok := info[s.ServerID]; ok {
+			name := "standard"
+			if details.Pro() {
+				name = "pro"
+			}
+			groups = append(groups, name)
+			inv.GroupVars[name] = Vars{"mikrus_pro": details.Pro()}
+		}
+		inv.Hosts = append(inv.Hosts, Host{
+			Name:   r.AliasPrefix + s.ServerID,
+			Groups: groups,
+			Vars:   vars,
+		})
+	}
+	sort.Slice(inv.Hosts, func(i, j int) bool { return inv.Hosts[i].Name < inv.Hosts[j].Name })
+	return inv
+}
+
+var nonIdentRE = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// groupSuffix makes the value usable in Ansible group names.
+func groupSuffix(value string) string {
+	return strings.Trim(nonIdentRE.ReplaceAllString(strings.TrimSpace(value), "_"), "_")
+}
+
+// number returns the value as an integer when it is one, so
+// Ansible can compare it, and as a string otherwise.
+func number(value string) any {
+	if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return n
+	}
+	return value
+}
+
+// Groups returns names of all groups with their hosts, sorted.
+func (inv Inventory) Groups() map[string][]string {
+	groups := map[string][]string{}
+	for _, h := range inv.Hosts {
+		for _, g := range h.Groups {
+			groups[g] = append(groups[g], h.Name)
+		}
+	}
+	for _, hosts := range groups {
+		slices.Sort(hosts)
+	}
+	return groups
+}
+
+// HostVars returns variables of the host.
+func (inv Inventory) HostVars(name string) (Vars, bool) {
+	for _, h := range inv.Hosts {
+		if h.Name == name {
+			return h.Vars, true
+		}
+	}
+	return nil, false
+}
+
+// List returns the inventory in the format of the --list output
+// of Ansible dynamic inventory scripts, with host variables in _meta.
+func (inv Inventory) List() map[string]any {
+	list := map[string]any{}
+	groups := inv.Groups()
+	names := make([]string, 0, len(groups))
+	for name, hosts := range groups {
+		names = append(names, name)
+		group := map[string]any{"hosts": hosts}
+		if vars, ok := inv.GroupVars[name]; ok {
+			group["vars"] = vars
+		}
+		list[name] = group
+	}
+	slices.Sort(names)
+	list["all"] = map[string]any{"children": names}
+	hostvars := map[string]Vars{}
+	for _, h := range inv.Hosts {
+		hostvars[h.Name] = h.Vars
+	}
+	list["_meta"] = map[string]any{"hostvars": hostvars}
+	return list
+}
+
+// WriteJSON writes the inventory as JSON in the --list format.
+func (inv Inventory) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inv.List())
+}
+
+// WriteYAML writes the inventory in the Ansible YAML inventory format.
+func (inv Inventory) WriteYAML(w io.Writer) error {
+	children := map[string]any{}
+	for name, hosts := range inv.Groups() {
+		members := map[string]any{}
+		for _, h := range hosts {
+			members[h] = nil
+			if name == Group {
+				vars, _ := inv.HostVars(h)
+				members[h] = vars
+			}
+		}
+		group := map[string]any{"hosts": members}
+		if vars, ok := inv.GroupVars[name]; ok {
+			group["vars"] = vars
+		}
+		children[name] = group
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]any{"all": map[string]any{"children": children}}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// WriteINI writes the inventory in the Ansible INI inventory format.
+// Host variables are set in the mikrus group.
+func (inv Inventory) WriteINI(w io.Writer) error {
+	groups := inv.Groups()
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != Group {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]\n", Group)
+	for _, h := range inv.Hosts {
+		b.WriteString(h.Name)
+		for _, k := range sortedKeys(h.Vars) {
+			fmt.Fprintf(&b, " %s=%s", k, iniValue(h.Vars[k]))
+		}
+		b.WriteString("\n")
+	}
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n[%s]\n", name)
+		for _, h := range groups[name] {
+			b.WriteString(h + "\n")
+		}
+		if vars, ok := inv.GroupVars[name]; ok {
+			fmt.Fprintf(&b, "\n[%s:vars]\n", name)
+			for _, k := range sortedKeys(vars) {
+				fmt.Fprintf(&b, "%s=%s\n", k, iniValue(vars[k]))
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sortedKeys(vars Vars) []string {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// iniValue formats the value, quoting strings with spaces or quotes.
+func iniValue(v any) string {
+	s := fmt.Sprint(v)
+	if strings.ContainsAny(s, " \t\"'#;=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,176 @@
+package inventory_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/inventory"
+	"github.com/qba73/mikrus/sshconfig"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	servers = []mikrus.ServerShort{
+		{ServerID: "j230", ServerName: "web app", Expires: "2026-06-08 00:00:00", ParamRam: "1024", ParamDisk: "10"},
+		{ServerID: "a101", Expires: "2025-01-01 00:00:00", ParamRam: "2048", ParamDisk: "10"},
+	}
+	info     = map[string]mikrus.Server{"j230": {ServerID: "j230", MikrusPro: "nie"}}
+	resolver = sshconfig.Resolver{Hosts: map[string]string{"j": "srv07.mikr.us"}}
+)
+
+func TestBuild_SetsConnectionVarsAndGroups(t *testing.T) {
+	t.Parallel()
+	inv := inventory.Build(servers, info, resolver)
+	want := []inventory.Host{
+		{
+			Name:   "a101",
+			Groups: []string{"mikrus", "ram_2048", "disk_10"},
+			Vars: inventory.Vars{
+				"mikrus_server_id": "a101",
+				"mikrus_expires":   "2025-01-01 00:00:00",
+				"ansible_port":     10101,
+			},
+		},
+		{
+			Name:   "j230",
+			Groups: []string{"mikrus", "ram_1024", "disk_10", "standard"},
+			Vars: inventory.Vars{
+				"mikrus_server_id":   "j230",
+				"mikrus_server_name": "web app",
+				"mikrus_expires":     "2026-06-08 00:00:00",
+				"ansible_host":       "srv07.mikr.us",
+				"ansible_port":       10230,
+				"ansible_user":       "root",
+			},
+		},
+	}
+	if !cmp.Equal(want, inv.Hosts) {
+		t.Error(cmp.Diff(want, inv.Hosts))
+	}
+	wantGroupVars := map[string]inventory.Vars{
+		"ram_1024": {"mikrus_ram": 1024},
+		"ram_2048": {"mikrus_ram": 2048},
+		"disk_10":  {"mikrus_disk": 10},
+		"standard": {"mikrus_pro": false},
+	}
+	if !cmp.Equal(wantGroupVars, inv.GroupVars) {
+		t.Error(cmp.Diff(wantGroupVars, inv.GroupVars))
+	}
+}
+
+func TestWriteINI_WritesHostsAndGroupVars(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := inventory.Build(servers, info, resolver).WriteINI(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `[mikrus]
+a101 ansible_port=10101 mikrus_expires="2025-01-01 00:00:00" mikrus_server_id=a101
+j230 ansible_host=srv07.mikr.us ansible_port=10230 ansible_user=root mikrus_expires="2026-06-08 00:00:00" mikrus_server_id=j230 mikrus_server_name="web app"
+
+[disk_10]
+a101
+j230
+
+[disk_10:vars]
+mikrus_disk=10
+
+[ram_1024]
+j230
+
+[ram_1024:vars]
+mikrus_ram=1024
+
+[ram_2048]
+a101
+
+[ram_2048:vars]
+mikrus_ram=2048
+
+[standard]
+j230
+
+[standard:vars]
+mikrus_pro=false
+`
+	if got := b.String(); got != want {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestWriteYAML_NestsGroupsUnderAll(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := inventory.Build(servers, info, resolver).WriteYAML(&b); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		All struct {
+			Children map[string]struct {
+				Hosts map[string]map[string]any `yaml:"hosts"`
+				Vars  map[string]any            `yaml:"vars"`
+			} `yaml:"children"`
+		} `yaml:"all"`
+	}
+	if err := yaml.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	groups := got.All.Children
+	if port := groups["mikrus"].Hosts["j230"]["ansible_port"]; port != 10230 {
+		t.Errorf("want ansible_port 10230 of j230, got %v", port)
+	}
+	if _, ok := groups["ram_2048"].Hosts["a101"]; !ok {
+		t.Errorf("want a101 in ram_2048 group, got %v", groups["ram_2048"].Hosts)
+	}
+	if ram := groups["ram_2048"].Vars["mikrus_ram"]; ram != 2048 {
+		t.Errorf("want mikrus_ram 2048, got %v", ram)
+	}
+}
+
+func TestList_ReturnsDynamicInventory(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	if err := inventory.Build(servers, info, resolver).WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		All struct {
+			Children []string `json:"children"`
+		} `json:"all"`
+		Mikrus struct {
+			Hosts []string `json:"hosts"`
+		} `json:"mikrus"`
+		Meta struct {
+			Hostvars map[string]map[string]any `json:"hostvars"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	wantChildren := []string{"disk_10", "mikrus", "ram_1024", "ram_2048", "standard"}
+	if !cmp.Equal(wantChildren, got.All.Children) {
+		t.Error(cmp.Diff(wantChildren, got.All.Children))
+	}
+	if !cmp.Equal([]string{"a101", "j230"}, got.Mikrus.Hosts) {
+		t.Errorf("want hosts a101 and j230, got %v", got.Mikrus.Hosts)
+	}
+	if host := got.Meta.Hostvars["j230"]["ansible_host"]; host != "srv07.mikr.us" {
+		t.Errorf("want ansible_host of j230 in _meta, got %v", host)
+	}
+}
+
+func TestHostVars_ReturnsVarsOfKnownHosts(t *testing.T) {
+	t.Parallel()
+	inv := inventory.Build(servers, info, resolver)
+	vars, ok := inv.HostVars("j230")
+	if !ok || vars["ansible_user"] != "root" {
+		t.Errorf("want vars of j230, got %v", vars)
+	}
+	if _, ok := inv.HostVars("bogus"); ok {
+		t.Error("want no vars of unknown host")
+	}
+}
@@ -0,0 +1,239 @@
+// Package logarchive keeps log entries of Mikrus servers in local
+// files, so the history of tasks outlives the window of the most
+// recent entries returned by the API.
+//
+// Every server has its own file with one JSON encoded log entry per
+// line, ordered by entry ID.
+package logarchive
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Archive is a collection of log files in a directory.
+// It is safe for concurrent use within a single process.
+type Archive struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// Open opens the archive in dir, creating the directory if needed.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Archive{dir: dir}, nil
+}
+
+func (a *Archive) path(serverID string) (string, error) {
+	if serverID == "" || strings.ContainsAny(serverID, `/\.`) {
+		return "", fmt.Errorf("invalid server ID %q", serverID)
+	}
+	return filepath.Join(a.dir, serverID+".jsonl"), nil
+}
+
+// Merge adds log entries to the archive of the server. Entries already
+// archived are replaced when they changed, like pending tasks that got
+// done. It returns the number of added and updated entries.
+func (a *Archive) Merge(serverID string, logs mikrus.Logs) (added, updated int, err error) {
+	path, err := a.path(serverID)
+	if err != nil {
+		return 0, 0, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	all, err := a.read(serverID)
+	if err != nil {
+		return 0, 0, err
+	}
+	index := make(map[string]int, len(all))
+	for i, l := range all {
+		index[l.ID] = i
+	}
+	for _, l := range logs {
+		if l.ServerID == "" {
+			l.ServerID = serverID
+		}
+		i, ok := index[l.ID]
+		switch {
+		case !ok:
+			index[l.ID] = len(all)
+			all = append(all, l)
+			added++
+		case all[i] != l:
+			all[i] = l
+			updated++
+		}
+	}
+	if added == 0 && updated == 0 {
+		return 0, 0, nil
+	}
+	slices.SortStableFunc(all, compareIDs)
+	if err := a.write(path, all); err != nil {
+		return 0, 0, err
+	}
+	return added, updated, nil
+}
+
+// compareIDs orders entries by numeric IDs, and by IDs as strings
+// when they are not numbers.
+func compareIDs(x, y mikrus.Log) int {
+	i, errX := strconv.ParseUint(x.ID, 10, 64)
+	j, errY := strconv.ParseUint(y.ID, 10, 64)
+	if errX != nil || errY != nil {
+		return strings.Compare(x.ID, y.ID)
+	}
+	switch {
+	case i < j:
+		return -1
+	case i > j:
+		return 1
+	}
+	return 0
+}
+
+// Query selects archived log entries.
+type Query struct {
+	// Task selects entries of the task, like "restart", if not empty.
+	Task string
+
+	// From and To select entries created in the time range [From, To].
+	// Zero From or To leave the range open.
+	From, To time.Time
+
+	// Text selects entries with output containing all the words,
+	// ignoring case.
+	Text string
+}
+
+// Match reports whether the entry matches the query. Entries with
+// invalid creation dates match only queries without a time range.
+func (q Query) Match(l mikrus.Log) bool {
+	if q.Task != "" && !strings.EqualFold(q.Task, l.Task) {
+		return false
+	}
+	if !q.From.IsZero() || !q.To.IsZero() {
+		created, err := l.CreatedAt()
+		if err != nil {
+			return false
+		}
+		if !q.From.IsZero() && created.Before(q.From) {
+			return false
+		}
+		if !q.To.IsZero() && created.After(q.To) {
+			return false
+		}
+	}
+	output := strings.ToLower(l.Output)
+	for _, word := range strings.Fields(strings.ToLower(q.Text)) {
+		if !strings.Contains(output, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Search returns archived entries of the server matching the query,
+// newest first like the API.
+func (a *Archive) Search(serverID string, q Query) (mikrus.Logs, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	all, err := a.read(serverID)
+	if err != nil {
+		return nil, err
+	}
+	var logs mikrus.Logs
+	for i := len(all) - 1; i >= 0; i-- {
+		if q.Match(all[i]) {
+			logs = append(logs, all[i])
+		}
+	}
+	return logs, nil
+}
+
+// Servers returns IDs of servers with archived logs.
+func (a *Archive) Servers() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(a.dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(files))
+	for _, f := range files {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(f), ".jsonl"))
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// write replaces the archive file with the entries. It must be
+// called with a.mu held.
+func (a *Archive) write(path string, logs mikrus.Logs) error {
+	tmp, err := os.CreateTemp(a.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, l := range logs {
+		if err := enc.Encode(l); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// read returns all archived entries of the server, oldest first.
+// It must be called with a.mu held.
+func (a *Archive) read(serverID string) (mikrus.Logs, error) {
+	path, err := a.path(serverID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var logs mikrus.Logs
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var l mikrus.Log
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		logs = append(logs, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
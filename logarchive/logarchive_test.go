@@ -0,0 +1,146 @@
+package logarchive_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/logarchive"
+)
+
+var (
+	password = mikrus.Log{ID: "9", ServerID: "j230", Task: "password", WhenCreated: "2024-06-01 12:00:00", WhenDone: "2024-06-01 12:00:01", Output: "OK"}
+	upgrade  = mikrus.Log{ID: "10", ServerID: "j230", Task: "upgrade", WhenCreated: "2024-06-04 08:00:00", WhenDone: "2024-06-04 08:03:00", Output: "Dodaje: +256MB RAM [succes] GOTOWE!"}
+	pending  = mikrus.Log{ID: "11", ServerID: "j230", Task: "restart", WhenCreated: "2024-06-05 10:00:00"}
+	restart  = mikrus.Log{ID: "11", ServerID: "j230", Task: "restart", WhenCreated: "2024-06-05 10:00:00", WhenDone: "2024-06-05 10:00:12", Output: "OK"}
+)
+
+func openArchive(t *testing.T) *logarchive.Archive {
+	t.Helper()
+	a, err := logarchive.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestMerge_KeepsEntriesBeyondAPIWindow(t *testing.T) {
+	t.Parallel()
+	a := openArchive(t)
+	added, updated, err := a.Merge("j230", mikrus.Logs{upgrade, password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 2 || updated != 0 {
+		t.Errorf("want 2 added and 0 updated, got %d and %d", added, updated)
+	}
+	// The password entry dropped out of the API window.
+	added, updated, err = a.Merge("j230", mikrus.Logs{pending, upgrade})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || updated != 0 {
+		t.Errorf("want 1 added and 0 updated, got %d and %d", added, updated)
+	}
+	added, updated, err = a.Merge("j230", mikrus.Logs{restart, upgrade})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 0 || updated != 1 {
+		t.Errorf("want 0 added and 1 updated, got %d and %d", added, updated)
+	}
+
+	got, err := a.Search("j230", logarchive.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mikrus.Logs{restart, upgrade, password}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestSearch_FiltersByTaskDateAndText(t *testing.T) {
+	t.Parallel()
+	a := openArchive(t)
+	if _, _, err := a.Merge("j230", mikrus.Logs{restart, upgrade, password}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		query logarchive.Query
+		want  mikrus.Logs
+	}{
+		{"task", logarchive.Query{Task: "Restart"}, mikrus.Logs{restart}},
+		{"from", logarchive.Query{From: time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC)}, mikrus.Logs{restart, upgrade}},
+		{"to", logarchive.Query{To: time.Date(2024, time.June, 4, 0, 0, 0, 0, time.UTC)}, mikrus.Logs{password}},
+		{"text", logarchive.Query{Text: "gotowe ram"}, mikrus.Logs{upgrade}},
+		{"text and task", logarchive.Query{Text: "ok", Task: "password"}, mikrus.Logs{password}},
+		{"no match", logarchive.Query{Text: "błąd"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Search("j230", tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestMerge_OrdersEntriesByNumericID(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	a, err := logarchive.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.Merge("j230", mikrus.Logs{upgrade}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.Merge("j230", mikrus.Logs{password}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "j230.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"9","server_id":"j230","task":"password","when_created":"2024-06-01 12:00:00","when_done":"2024-06-01 12:00:01","output":"OK"}
+{"id":"10","server_id":"j230","task":"upgrade","when_created":"2024-06-04 08:00:00","when_done":"2024-06-04 08:03:00","output":"Dodaje: +256MB RAM [succes] GOTOWE!"}
+`
+	if got := string(data); got != want {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestServers_ListsArchivedServers(t *testing.T) {
+	t.Parallel()
+	a := openArchive(t)
+	for _, id := range []string{"j230", "a101"} {
+		if _, _, err := a.Merge(id, mikrus.Logs{password}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := a.Servers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a101", "j230"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestMerge_RejectsInvalidServerID(t *testing.T) {
+	t.Parallel()
+	a := openArchive(t)
+	if _, _, err := a.Merge("../j230", mikrus.Logs{password}); err == nil {
+		t.Fatal("want error for invalid server ID, got nil")
+	}
+}
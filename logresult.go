@@ -0,0 +1,167 @@
+package mikrus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Result represents the outcome of a task parsed from its log entry.
+type Result struct {
+	// Task is the English name of the task, like "sshkey".
+	Task string `json:"task"`
+
+	// Done reports whether the task finished.
+	Done bool `json:"done"`
+
+	// Success and Failed report whether the output of the finished
+	// task shows it succeeded or failed. Both are false when the
+	// output is not recognised.
+	Success bool `json:"success"`
+	Failed  bool `json:"failed"`
+
+	// Message is an English summary of the task output.
+	Message string `json:"message"`
+
+	// Upgrade holds server parameters changed by upgrade tasks.
+	Upgrade *Upgrade `json:"upgrade,omitempty"`
+}
+
+// String implements stringer interface.
+func (r Result) String() string {
+	switch {
+	case !r.Done:
+		return "pending"
+	case r.Success:
+		return "succeeded: " + r.Message
+	case r.Failed:
+		return "failed: " + r.Message
+	default:
+		return "done: " + r.Message
+	}
+}
+
+// Upgrade represents server parameters before and after an upgrade.
+// RAM is in megabytes and disk in gigabytes.
+type Upgrade struct {
+	OldRAM    int `json:"old_ram"`
+	OldDisk   int `json:"old_disk"`
+	AddedRAM  int `json:"added_ram"`
+	AddedDisk int `json:"added_disk"`
+	NewRAM    int `json:"new_ram"`
+	NewDisk   int `json:"new_disk"`
+}
+
+// resultParsers parse outputs of tasks into results with Success,
+// Failed and Message set. Only formats seen in real logs are listed;
+// outputs they don't recognise are handled by parseOutput.
+var resultParsers = map[string]func(output string) Result{
+	"upgrade":  parseUpgrade,
+	"restart":  parseOK("Server restarted"),
+	"kluczssh": parseSSHKey,
+}
+
+// taskNames translates task names to English.
+var taskNames = map[string]string{
+	"kluczssh":   "sshkey",
+	"amfetamina": "boost",
+}
+
+// Result parses the output of the task. Outputs of other tasks are
+// summarised as they are and considered failed when they report
+// errors; whether they succeeded is unknown otherwise.
+func (l Log) Result() Result {
+	task := l.Task
+	if name, ok := taskNames[task]; ok {
+		task = name
+	}
+	if l.WhenDone == "" {
+		return Result{Task: task}
+	}
+	parse, ok := resultParsers[l.Task]
+	if !ok {
+		parse = parseOutput
+	}
+	r := parse(l.Output)
+	r.Task = task
+	r.Done = true
+	return r
+}
+
+var failureRE = regexp.MustCompile(`(?i)\b(error|fail(ed|ure)?|błąd|blad|nie udało)`)
+
+// parseOutput returns the cleaned up output, failed when it reports
+// errors and with unknown success otherwise.
+func parseOutput(output string) Result {
+	return Result{
+		Failed:  failureRE.MatchString(output),
+		Message: strings.Join(strings.Fields(cleanup(output)), " "),
+	}
+}
+
+// parseOK returns a parser of tasks reporting success with "OK".
+func parseOK(message string) func(string) Result {
+	return func(output string) Result {
+		if strings.EqualFold(strings.TrimSpace(output), "OK") {
+			return Result{Success: true, Message: message}
+		}
+		return parseOutput(output)
+	}
+}
+
+func parseSSHKey(output string) Result {
+	if strings.Contains(output, "Wrzuciłem klucz SSH") {
+		return Result{Success: true, Message: "Uploaded SSH key"}
+	}
+	return parseOutput(output)
+}
+
+var (
+	upgradeOldRE   = regexp.MustCompile(`Aktualne parametry: (\d+) RAM / (\d+) DYSK`)
+	upgradeAddedRE = regexp.MustCompile(`Dodaje: \+?(\d+) ?MB RAM oraz \+?(\d+) ?GB dysku`)
+	upgradeNewRE   = regexp.MustCompile(`Po zmianie: (\d+) MB / (\d+) GB`)
+)
+
+// parseUpgrade parses output like:
+//
+//	=== Aktualne parametry: 768 RAM / 10 DYSK
+//	2 / 20
+//	Dodaje: +256MB RAM oraz +0GB dysku
+//	Po zmianie: 1024 MB / 10 GB
+//	[succes] GOTOWE!
+func parseUpgrade(output string) Result {
+	var u Upgrade
+	old := matchInts(upgradeOldRE, output, &u.OldRAM, &u.OldDisk)
+	added := matchInts(upgradeAddedRE, output, &u.AddedRAM, &u.AddedDisk)
+	changed := matchInts(upgradeNewRE, output, &u.NewRAM, &u.NewDisk)
+	if !old || !changed || failureRE.MatchString(output) {
+		return parseOutput(output)
+	}
+	if !added {
+		u.AddedRAM, u.AddedDisk = u.NewRAM-u.OldRAM, u.NewDisk-u.OldDisk
+	}
+	return Result{
+		Success: strings.Contains(output, "GOTOWE") || strings.Contains(output, "[succes"),
+		Message: fmt.Sprintf("Upgraded from %d MB RAM / %d GB disk to %d MB RAM / %d GB disk (+%d MB RAM, +%d GB disk)",
+			u.OldRAM, u.OldDisk, u.NewRAM, u.NewDisk, u.AddedRAM, u.AddedDisk),
+		Upgrade: &u,
+	}
+}
+
+// matchInts sets dst to integers captured by the regexp in s.
+// It reports whether the regexp matched.
+func matchInts(re *regexp.Regexp, s string, dst ...*int) bool {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return false
+	}
+	for i, d := range dst {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return false
+		}
+		*d = n
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package mikrus_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+)
+
+func TestLogResultParsesKnownTasks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		log  mikrus.Log
+		want mikrus.Result
+	}{
+		{
+			name: "upgrade",
+			log: mikrus.Log{
+				Task:     "upgrade",
+				WhenDone: "2024-06-05 09:00:04",
+				Output:   "=== Aktualne parametry: 768 RAM / 10 DYSK\n2 / 20\nDodaje: +256MB RAM oraz +0GB dysku\nPo zmianie: 1024 MB / 10 GB\n[succes] GOTOWE!\n",
+			},
+			want: mikrus.Result{
+				Task:    "upgrade",
+				Done:    true,
+				Success: true,
+				Message: "Upgraded from 768 MB RAM / 10 GB disk to 1024 MB RAM / 10 GB disk (+256 MB RAM, +0 GB disk)",
+				Upgrade: &mikrus.Upgrade{OldRAM: 768, OldDisk: 10, AddedRAM: 256, NewRAM: 1024, NewDisk: 10},
+			},
+		},
+		{
+			name: "failed upgrade",
+			log:  mikrus.Log{Task: "upgrade", WhenDone: "2024-06-05 09:00:04", Output: "Błąd: brak środków\n"},
+			want: mikrus.Result{Task: "upgrade", Done: true, Failed: true, Message: "Błąd: brak środków"},
+		},
+		{
+			name: "restart",
+			log:  mikrus.Log{Task: "restart", WhenDone: "2024-06-05 09:58:07", Output: "OK\n"},
+			want: mikrus.Result{Task: "restart", Done: true, Success: true, Message: "Server restarted"},
+		},
+		{
+			name: "failed restart",
+			log:  mikrus.Log{Task: "restart", WhenDone: "2024-06-05 09:58:07", Output: "Restart failed\n"},
+			want: mikrus.Result{Task: "restart", Done: true, Failed: true, Message: "Restart failed"},
+		},
+		{
+			name: "unrecognised restart output",
+			log:  mikrus.Log{Task: "restart", WhenDone: "2024-06-05 09:58:07", Output: "Server restarted\n  OK"},
+			want: mikrus.Result{Task: "restart", Done: true, Message: "Server restarted OK"},
+		},
+		{
+			name: "SSH key",
+			log:  mikrus.Log{Task: "kluczssh", WhenDone: "2024-06-05 10:06:01", Output: "Wrzuciłem klucz SSH\n"},
+			want: mikrus.Result{Task: "sshkey", Done: true, Success: true, Message: "Uploaded SSH key"},
+		},
+		{
+			name: "unknown task",
+			log:  mikrus.Log{Task: "amfetamina", WhenDone: "2024-06-05 10:06:01", Output: "Dodano\n+512MB RAM"},
+			want: mikrus.Result{Task: "boost", Done: true, Message: "Dodano 512MB RAM"},
+		},
+		{
+			name: "pending",
+			log:  mikrus.Log{Task: "restart", Output: ""},
+			want: mikrus.Result{Task: "restart"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.log.Result()
+			if !cmp.Equal(tt.want, got) {
+				t.Error(cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestLogResultStringSummarisesOutcome(t *testing.T) {
+	t.Parallel()
+
+	tests := map[mikrus.Log]string{
+		{Task: "restart", WhenDone: "2024-06-05 09:58:07", Output: "OK"}:   "succeeded: Server restarted",
+		{Task: "restart", WhenDone: "2024-06-05 09:58:07", Output: "błąd"}: "failed: błąd",
+		{Task: "password", WhenDone: "2024-06-05 09:17:02", Output: "OK"}:  "done: OK",
+		{Task: "restart"}: "pending",
+	}
+	for l, want := range tests {
+		if got := l.Result().String(); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
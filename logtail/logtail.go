@@ -0,0 +1,197 @@
+// Package logtail follows log entries of a Mikrus server. The API
+// returns only the most recent entries, so the tail polls it and
+// reports entries it has not reported before, and entries of pending
+// tasks once they are done.
+package logtail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Source provides log entries of a server.
+// *mikrus.Client implements Source.
+type Source interface {
+	Logs() (mikrus.Logs, error)
+}
+
+// Kind is the kind of an event.
+type Kind string
+
+const (
+	// New is reported for entries seen for the first time.
+	New Kind = "new"
+
+	// Done is reported for previously pending entries that got done.
+	Done Kind = "done"
+)
+
+// Event reports a log entry.
+type Event struct {
+	Kind Kind `json:"event"`
+	mikrus.Log
+}
+
+// Filter selects log entries to report.
+type Filter struct {
+	// Since skips entries created before the time, if not zero.
+	Since time.Time
+
+	// Task selects entries of the task, like "restart", if not empty.
+	Task string
+}
+
+// Match reports whether the entry passes the filter. Entries with
+// invalid creation dates pass the Since filter.
+func (f Filter) Match(l mikrus.Log) bool {
+	if f.Task != "" && !strings.EqualFold(f.Task, l.Task) {
+		return false
+	}
+	if !f.Since.IsZero() {
+		if created, err := l.CreatedAt(); err == nil && created.Before(f.Since) {
+			return false
+		}
+	}
+	return true
+}
+
+// Cursor records log entries already reported.
+type Cursor struct {
+	// Seen maps IDs of reported entries to whether they were done.
+	Seen map[string]bool `json:"seen"`
+}
+
+// NewCursor returns an empty cursor.
+func NewCursor() *Cursor {
+	return &Cursor{Seen: map[string]bool{}}
+}
+
+// LoadCursor reads the cursor from the file. It returns an empty
+// cursor if the file does not exist.
+func LoadCursor(path string) (*Cursor, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewCursor(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := NewCursor()
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	if c.Seen == nil {
+		c.Seen = map[string]bool{}
+	}
+	return c, nil
+}
+
+// Save writes the cursor to the file, creating its directory
+// when needed.
+func (c *Cursor) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Tail reports new and done log entries of a server.
+type Tail struct {
+	Source Source
+	Filter Filter
+	Cursor *Cursor
+
+	// ErrorLog specifies an optional logger for errors getting logs
+	// in Follow. If nil, errors are not logged.
+	ErrorLog *log.Logger
+}
+
+// Poll gets log entries once and returns events for entries not
+// reported before, oldest first, and updates the cursor.
+func (t *Tail) Poll() ([]Event, error) {
+	logs, err := t.Source.Logs()
+	if err != nil {
+		return nil, err
+	}
+	if t.Cursor == nil {
+		t.Cursor = NewCursor()
+	}
+	var events []Event
+	// The API returns the most recent entries first.
+	for i := len(logs) - 1; i >= 0; i-- {
+		l := logs[i]
+		if !t.Filter.Match(l) {
+			continue
+		}
+		done := l.WhenDone != ""
+		wasDone, seen := t.Cursor.Seen[l.ID]
+		switch {
+		case !seen:
+			events = append(events, Event{Kind: New, Log: l})
+		case done && !wasDone:
+			events = append(events, Event{Kind: Done, Log: l})
+		}
+		t.Cursor.Seen[l.ID] = done
+	}
+	t.prune(logs)
+	return events, nil
+}
+
+// prune forgets entries no longer returned by the API, so the cursor
+// does not grow. Entries never come back once they drop out of the
+// list of recent entries.
+func (t *Tail) prune(logs mikrus.Logs) {
+	if len(logs) == 0 {
+		return
+	}
+	current := make(map[string]bool, len(logs))
+	for _, l := range logs {
+		current[l.ID] = true
+	}
+	for id := range t.Cursor.Seen {
+		if !current[id] {
+			delete(t.Cursor.Seen, id)
+		}
+	}
+}
+
+// Follow polls log entries every interval and calls fn for every
+// event until the context is cancelled or fn returns an error.
+// Errors getting logs are logged and do not stop following.
+func (t *Tail) Follow(ctx context.Context, interval time.Duration, fn func(Event) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		events, err := t.Poll()
+		if err != nil && t.ErrorLog != nil {
+			t.ErrorLog.Print(err)
+		}
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package logtail_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/logtail"
+)
+
+type fakeSource struct {
+	logs mikrus.Logs
+	err  error
+}
+
+func (f *fakeSource) Logs() (mikrus.Logs, error) { return f.logs, f.err }
+
+var (
+	restartPending = mikrus.Log{ID: "3", ServerID: "j230", Task: "restart", WhenCreated: "2024-06-05 10:00:00"}
+	restartDone    = mikrus.Log{ID: "3", ServerID: "j230", Task: "restart", WhenCreated: "2024-06-05 10:00:00", WhenDone: "2024-06-05 10:00:12", Output: "OK"}
+	upgrade        = mikrus.Log{ID: "2", ServerID: "j230", Task: "upgrade", WhenCreated: "2024-06-04 08:00:00", WhenDone: "2024-06-04 08:03:00"}
+	password       = mikrus.Log{ID: "1", ServerID: "j230", Task: "password", WhenCreated: "2024-06-01 12:00:00", WhenDone: "2024-06-01 12:00:01"}
+)
+
+func TestTail_ReportsNewEntriesOldestFirst(t *testing.T) {
+	t.Parallel()
+	tail := &logtail.Tail{Source: &fakeSource{logs: mikrus.Logs{upgrade, password}}}
+	got, err := tail.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []logtail.Event{{Kind: logtail.New, Log: password}, {Kind: logtail.New, Log: upgrade}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTail_DeduplicatesAndReportsDoneEntries(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{logs: mikrus.Logs{upgrade, password}}
+	tail := &logtail.Tail{Source: src}
+	if _, err := tail.Poll(); err != nil {
+		t.Fatal(err)
+	}
+
+	src.logs = mikrus.Logs{restartPending, upgrade, password}
+	got, err := tail.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []logtail.Event{{Kind: logtail.New, Log: restartPending}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	src.logs = mikrus.Logs{restartDone, upgrade, password}
+	got, err = tail.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []logtail.Event{{Kind: logtail.Done, Log: restartDone}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+
+	got, err = tail.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no events, got %v", got)
+	}
+}
+
+func TestTail_FiltersByTaskAndCreationTime(t *testing.T) {
+	t.Parallel()
+	tail := &logtail.Tail{
+		Source: &fakeSource{logs: mikrus.Logs{restartDone, upgrade, password}},
+		Filter: logtail.Filter{Task: "RESTART", Since: time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	got, err := tail.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []logtail.Event{{Kind: logtail.New, Log: restartDone}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTail_PrunesEntriesNoLongerReturned(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{logs: mikrus.Logs{upgrade, password}}
+	tail := &logtail.Tail{Source: src}
+	if _, err := tail.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	src.logs = mikrus.Logs{restartDone, upgrade}
+	if _, err := tail.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"2": true, "3": true}
+	if !cmp.Equal(want, tail.Cursor.Seen) {
+		t.Error(cmp.Diff(want, tail.Cursor.Seen))
+	}
+}
+
+func TestCursor_SavedCursorSkipsReportedEntries(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "logs", "j230.json")
+	src := &fakeSource{logs: mikrus.Logs{restartPending, upgrade}}
+
+	c, err := logtail.LoadCursor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := &logtail.Tail{Source: src, Cursor: c}
+	if _, err := first.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = logtail.LoadCursor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.logs = mikrus.Logs{restartDone, upgrade, password}
+	second := &logtail.Tail{Source: src, Cursor: c}
+	got, err := second.Poll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []logtail.Event{{Kind: logtail.New, Log: password}, {Kind: logtail.Done, Log: restartDone}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTail_FollowStopsOnCallbackError(t *testing.T) {
+	t.Parallel()
+	tail := &logtail.Tail{Source: &fakeSource{logs: mikrus.Logs{upgrade, password}}}
+	stop := errors.New("stop")
+	var calls int
+	err := tail.Follow(context.Background(), time.Millisecond, func(logtail.Event) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("want callback error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call, got %d", calls)
+	}
+}
+
+func TestTail_FollowKeepsPollingAfterErrors(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{err: errors.New("boom")}
+	tail := &logtail.Tail{Source: src}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var got []logtail.Event
+	err := tail.Follow(ctx, time.Millisecond, func(e logtail.Event) error {
+		got = append(got, e)
+		cancel()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no events, got %v", got)
+	}
+}
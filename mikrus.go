@@ -4,21 +4,67 @@ package mikrus
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+	_ "time/tzdata"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client represents Mikrus client.
+//
+// Client is safe for concurrent use by multiple goroutines. Concurrent
+// identical calls of read-only verbs, like Info or Stats, made with
+// a client or its copies share a single HTTP round trip.
 type Client struct {
 	apiKey     string
 	serverID   string
 	URL        string
 	HTTPClient *http.Client
+
+	// Cache caches responses of read-only verbs, like info, serwery
+	// and logs. If nil, responses are not cached.
+	Cache Cache
+
+	// CacheTTLs maps verbs to TTLs of their cached responses.
+	// Verbs not listed are not cached. If nil, DefaultCacheTTLs
+	// are used.
+	CacheTTLs map[string]time.Duration
+
+	// Logger logs every API call at the debug level with the verb,
+	// server, response status, latency and size. If nil, calls are
+	// not logged.
+	Logger *slog.Logger
+
+	// Tracer starts an OpenTelemetry span for every API call. If nil,
+	// calls are not traced.
+	Tracer trace.Tracer
+
+	// Metrics receives every API call, for example to count calls,
+	// errors and latency per verb. If nil, calls are not reported.
+	Metrics Metrics
+
+	// Redactor removes secrets from errors returned by the client, in
+	// addition to the API key, which is always removed. If nil, only
+	// DefaultRedactPatterns are used.
+	Redactor *Redactor
+
+	// MaxBodySize is the maximum size of API responses in bytes.
+	// Larger responses are not read and calls return an error wrapping
+	// ErrResponseTooLarge. If zero, DefaultMaxBodySize is used.
+	MaxBodySize int64
+
+	// flights coalesces concurrent identical calls. It is shared by
+	// copies of the client.
+	flights *flightGroup
 }
 
 // New creates and returns new Mikrus client.
@@ -30,13 +76,14 @@ func New(apiKey, srvID string) Client {
 		HTTPClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		flights: &flightGroup{},
 	}
 }
 
 // Info returns information about server associated with the API Key and ServerID.
 func (c *Client) Info() (Server, error) {
 	res := Server{}
-	if err := c.callAPI("info", &res); err != nil {
+	if err := c.callAPI("info", nil, &res); err != nil {
 		return Server{}, err
 	}
 	return res, nil
@@ -46,7 +93,7 @@ func (c *Client) Info() (Server, error) {
 // with the API Key and ServerID.
 func (c *Client) Servers() (Servers, error) {
 	servers := Servers{}
-	if err := c.callAPI("serwery", &servers); err != nil {
+	if err := c.callAPI("serwery", nil, &servers); err != nil {
 		return Servers{}, err
 	}
 	return servers, nil
@@ -56,35 +103,250 @@ func (c *Client) Servers() (Servers, error) {
 // with the API Key and ServerID.
 func (c *Client) Logs() (Logs, error) {
 	logs := Logs{}
-	if err := c.callAPI("logs", &logs); err != nil {
+	if err := c.callAPI("logs", nil, &logs); err != nil {
 		return Logs{}, err
 	}
 	return logs, nil
 }
 
-func (c *Client) callAPI(verb string, res any) error {
+// Stats returns memory, disk, uptime and process statistics
+// of the server associated with the API Key and ServerID.
+func (c *Client) Stats() (Stats, error) {
+	raw := struct {
+		Free   string `json:"free"`
+		DF     string `json:"df"`
+		Uptime string `json:"uptime"`
+		PS     string `json:"ps"`
+	}{}
+	if err := c.callAPI("stats", nil, &raw); err != nil {
+		return Stats{}, err
+	}
+	memory, err := ParseMemoryUsage(raw.Free)
+	if err != nil {
+		return Stats{}, err
+	}
+	disk, err := ParseDiskSpace(raw.DF)
+	if err != nil {
+		return Stats{}, err
+	}
+	uptime, err := ParseUptime(raw.Uptime)
+	if err != nil {
+		return Stats{}, err
+	}
+	processes, err := ParsePS(raw.PS)
+	if err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		Memory:    memory,
+		DiskSpace: disk,
+		Uptime:    uptime,
+		Processes: processes,
+	}, nil
+}
+
+// Ports returns TCP ports assigned to the server associated
+// with the API Key and ServerID.
+func (c *Client) Ports() ([]int, error) {
+	ports := []int{}
+	if err := c.callAPI("porty", nil, &ports); err != nil {
+		return nil, err
+	}
+	return ports, nil
+}
+
+// AssignDomain assigns the domain to the port of the server associated
+// with the API Key and ServerID.
+func (c *Client) AssignDomain(port int, domain string) (TaskResponse, error) {
+	params := url.Values{
+		"port":   {strconv.Itoa(port)},
+		"domain": {domain},
+	}
+	res := TaskResponse{}
+	if err := c.callAPI("domain", params, &res); err != nil {
+		return TaskResponse{}, err
+	}
+	return res, nil
+}
+
+// Boost temporarily adds resources to the server associated with
+// the API Key and ServerID (the amfetamina task).
+func (c *Client) Boost() (TaskResponse, error) {
+	res := TaskResponse{}
+	if err := c.callAPI("amfetamina", nil, &res); err != nil {
+		return TaskResponse{}, err
+	}
+	return res, nil
+}
+
+// Restart restarts the server associated with the API Key and ServerID.
+func (c *Client) Restart() (TaskResponse, error) {
+	res := TaskResponse{}
+	if err := c.callAPI("restart", nil, &res); err != nil {
+		return TaskResponse{}, err
+	}
+	return res, nil
+}
+
+// Exec runs the shell command on the server associated with the API Key
+// and ServerID, and returns its output.
+func (c *Client) Exec(command string) (string, error) {
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("command is required")
+	}
+	res := struct {
+		Output string `json:"output"`
+	}{}
+	if err := c.callAPI("exec", url.Values{"cmd": {command}}, &res); err != nil {
+		return "", err
+	}
+	return res.Output, nil
+}
+
+// UploadSSHKey uploads the OpenSSH public key to the server associated
+// with the API Key and ServerID. The key is added by a kluczssh task,
+// reported in server logs when done.
+func (c *Client) UploadSSHKey(pubKey string) (TaskResponse, error) {
+	key, err := ParseSSHPublicKey(pubKey)
+	if err != nil {
+		return TaskResponse{}, err
+	}
+	res := TaskResponse{}
+	if err := c.callAPI("kluczssh", url.Values{"pubkey": {key.String()}}, &res); err != nil {
+		return TaskResponse{}, err
+	}
+	return res, nil
+}
+
+// TaskResponse represents the response of verbs scheduling server tasks.
+type TaskResponse struct {
+	// TaskID is the ID of the log entry of the task, if reported.
+	TaskID  string `json:"task_id"`
+	Message string `json:"msg"`
+}
+
+// callAPI calls the API verb with the params and decodes the response
+// into res. The API key and server ID are always sent.
+//
+// Responses of read-only verbs are served from the cache when
+// possible, and calls to other verbs invalidate cached responses
+// of the server. Concurrent calls of the same read-only verb without
+// params share one request.
+func (c *Client) callAPI(verb string, params url.Values, res any) error {
+	scope := c.cacheScope()
+	ttl := c.cacheTTL(verb)
+	if len(params) > 0 {
+		ttl = 0
+	}
+	if ttl > 0 {
+		if body, ok := c.Cache.Get(scope, verb); ok {
+			c.logCacheHit(verb)
+			return c.decodeBytes(body, res)
+		}
+	}
+	if c.Cache != nil && !readOnlyVerbs[verb] {
+		// The call may change the server even if it fails.
+		defer generations.invalidate(scope, func() { c.Cache.Invalidate(scope) })
+	}
+
+	if !readOnlyVerbs[verb] || len(params) > 0 {
+		// Responses of other calls are not shared, so they are
+		// decoded as they are read.
+		return c.fetch(verb, params, func(b *bodyReader) error {
+			return c.decodeResponse(b, res)
+		})
+	}
+	read := func() ([]byte, error) {
+		var body []byte
+		err := c.fetch(verb, nil, func(b *bodyReader) error {
+			var err error
+			body, err = io.ReadAll(b)
+			return err
+		})
+		return body, err
+	}
+	// Responses read before an invalidation of the scope are neither
+	// cached nor shared with calls started after it.
+	gen := generations.get(scope)
+	var body []byte
+	var err error
+	if c.flights != nil {
+		body, err = c.flights.do(fmt.Sprintf("%s/%s/%d", scope, verb, gen), read)
+	} else {
+		body, err = read()
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.decodeBytes(body, res); err != nil {
+		return err
+	}
+	if ttl > 0 {
+		generations.ifCurrent(scope, gen, func() { c.Cache.Set(scope, verb, body, ttl) })
+	}
+	return nil
+}
+
+// fetch calls the API verb with the params and passes the response
+// body, limited to the maximum body size, to read.
+func (c *Client) fetch(verb string, params url.Values, read func(*bodyReader) error) (err error) {
+	call := Call{Verb: verb, ServerID: c.serverID}
+	span := c.startCall(verb)
+	start := time.Now()
+	var body *bodyReader
+	defer func() {
+		call.Duration = time.Since(start)
+		if body != nil {
+			call.Size = int(body.n)
+		}
+		call.Err = err
+		c.endCall(span, call)
+	}()
+
 	requestURL := c.URL + "/" + verb
 	val := url.Values{
 		"key": []string{c.apiKey},
 		"srv": []string{c.serverID},
 	}
+	for k, v := range params {
+		val[k] = v
+	}
 	resp, err := c.HTTPClient.PostForm(requestURL, val)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
-	respBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading response body: %w", err)
-	}
-	resp.Body.Close()
-	respString := string(respBytes)
-	resp.Body = io.NopCloser(strings.NewReader(respString))
+	call.Status = resp.StatusCode
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected response status %d: %q", resp.StatusCode, respString)
+		body = newBodyReader(resp.Body, snippetSize)
+		if _, err := io.Copy(io.Discard, body); err != nil && !errors.Is(err, ErrResponseTooLarge) {
+			return fmt.Errorf("reading response body: %w", err)
+		}
+		return fmt.Errorf("unexpected response status %d: %q", resp.StatusCode, c.snippet(body.snippet))
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		return fmt.Errorf("decoding error for %q: %w", respString, err)
+	body = newBodyReader(resp.Body, c.maxBodySize())
+	if err := read(body); err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			return fmt.Errorf("%s: %w", verb, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// decodeBytes decodes the response body read earlier into res.
+func (c *Client) decodeBytes(body []byte, res any) error {
+	return c.decodeResponse(newBodyReader(bytes.NewReader(body), int64(len(body))), res)
+}
+
+// decodeResponse decodes the response body into res. Errors include
+// the beginning of the body with secrets redacted.
+func (c *Client) decodeResponse(b *bodyReader, res any) error {
+	if err := json.NewDecoder(b).Decode(res); err != nil {
+		if errors.Is(err, ErrResponseTooLarge) {
+			return err
+		}
+		return fmt.Errorf("decoding error for %q: %w", c.snippet(b.snippet), err)
 	}
 	return nil
 }
@@ -98,6 +360,11 @@ type ServerShort struct {
 	ParamDisk  string `json:"param_disk"`
 }
 
+// ExpiresAt returns the server expiration date.
+func (s ServerShort) ExpiresAt() (time.Time, error) {
+	return parseTime(s.Expires)
+}
+
 const serversTemplate = `{{ range . }}
 Server ID: {{ .ServerID }}
 Server name: {{ .ServerName }}
@@ -131,6 +398,16 @@ type Server struct {
 	MikrusPro      string `json:"mikrus_pro"`
 }
 
+// ExpiresAt returns the server expiration date.
+func (s Server) ExpiresAt() (time.Time, error) {
+	return parseTime(s.Expires)
+}
+
+// Pro reports whether the server is a Mikrus Pro server.
+func (s Server) Pro() bool {
+	return s.MikrusPro == "tak"
+}
+
 const serverTemplate = `ServerID: {{ .ServerID }}
 Server name: {{ .ServerName }}
 Expiration date: {{ .Expires }}
@@ -160,6 +437,20 @@ type Log struct {
 	Output      string `json:"output"`
 }
 
+// CreatedAt returns when the task was created.
+func (l Log) CreatedAt() (time.Time, error) {
+	return parseTime(l.WhenCreated)
+}
+
+// DoneAt returns when the task was done. It returns zero time
+// if the task is still pending.
+func (l Log) DoneAt() (time.Time, error) {
+	if l.WhenDone == "" {
+		return time.Time{}, nil
+	}
+	return parseTime(l.WhenDone)
+}
+
 const logsTemplate = `{{ range .}}
 ID: {{ .ID }}
 Server ID: {{ .ServerID }}
@@ -167,6 +458,7 @@ Task: {{ .Task | toEng }}
 Created: {{ .WhenCreated }}
 Done: {{ .WhenDone }}
 Output: {{ .Output | cleanup | toEng }}
+Result: {{ .Result }}
 {{ end }}`
 
 // Logs represents a list of server logs.
@@ -181,6 +473,30 @@ func (l Logs) String() string {
 	return out
 }
 
+// timeLayout is the layout of dates returned by the Mikrus API.
+const timeLayout = "2006-01-02 15:04:05"
+
+// Location is the time zone of dates returned by the Mikrus API. The
+// API reports local time of its servers in Poland without a zone.
+var Location = mustLoadLocation("Europe/Warsaw")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// parseTime parses a date returned by the Mikrus API in Location.
+func parseTime(s string) (time.Time, error) {
+	t, err := time.ParseInLocation(timeLayout, s, Location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing date %q: %w", s, err)
+	}
+	return t, nil
+}
+
 // render takes a template and a data value, and returns
 // the string result of executing the template.
 func render(templateName string, value any) (string, error) {
@@ -7,9 +7,11 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
 )
 
 func TestMikrusReturnsInformationAboutServer(t *testing.T) {
@@ -38,6 +40,57 @@ func TestMikrusReturnsInformationAboutServer(t *testing.T) {
 	}
 }
 
+func TestServerExpiresAtParsesExpirationDate(t *testing.T) {
+	t.Parallel()
+
+	got, err := mikrus.Server{Expires: "2026-06-08 00:00:00"}.ExpiresAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, time.June, 7, 22, 0, 0, 0, time.UTC)
+	if !want.Equal(got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if _, err := (mikrus.ServerShort{Expires: "bogus"}).ExpiresAt(); err == nil {
+		t.Error("want error for invalid date, got nil")
+	}
+}
+
+func TestServerProReportsMikrusProStatus(t *testing.T) {
+	t.Parallel()
+
+	if (mikrus.Server{MikrusPro: "nie"}).Pro() {
+		t.Error("want standard server for mikrus_pro nie")
+	}
+	if !(mikrus.Server{MikrusPro: "tak"}).Pro() {
+		t.Error("want Pro server for mikrus_pro tak")
+	}
+}
+
+func TestLogTimesParseTaskDates(t *testing.T) {
+	t.Parallel()
+
+	l := mikrus.Log{WhenCreated: "2024-06-05 10:00:00", WhenDone: "2024-06-05 10:00:12"}
+	created, err := l.CreatedAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := l.DoneAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := done.Sub(created); got != 12*time.Second {
+		t.Errorf("want task done after 12s, got %v", got)
+	}
+	pending, err := mikrus.Log{WhenCreated: "2024-06-05 10:00:00"}.DoneAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pending.IsZero() {
+		t.Errorf("want zero time for pending task, got %v", pending)
+	}
+}
+
 func TestMikrusReturnsListOfServers(t *testing.T) {
 	t.Parallel()
 
@@ -117,6 +170,35 @@ func TestMikrusReturnsServerLogs(t *testing.T) {
 	}
 }
 
+func TestMikrusReturnsServerStats(t *testing.T) {
+	t.Parallel()
+
+	ts := newTestServer("/stats", []byte(statResponse), t)
+	defer ts.Close()
+
+	c := mikrus.New("dummyAPIKey", "dummyServerID")
+	c.HTTPClient = ts.Client()
+	c.URL = ts.URL
+
+	got, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMemory := mikrus.Memory{Total: 1024, Used: 43, Free: 816, Cache: 164, Available: 980}
+	if !cmp.Equal(wantMemory, got.Memory) {
+		t.Error(cmp.Diff(wantMemory, got.Memory))
+	}
+	if got.DiskSpace.Usage != "29%" {
+		t.Errorf("want disk usage 29%%, got %q", got.DiskSpace.Usage)
+	}
+	if got.Uptime.Uptime != 152*time.Hour+33*time.Minute {
+		t.Errorf("want uptime 152h33m, got %v", got.Uptime.Uptime)
+	}
+	if len(got.Processes) != 17 {
+		t.Errorf("want 17 processes, got %d", len(got.Processes))
+	}
+}
+
 func newTestServer(path string, data []byte, t *testing.T) *httptest.Server {
 	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -216,3 +298,96 @@ var (
     }
 ]`)
 )
+
+func TestMikrusReturnsServerPorts(t *testing.T) {
+	t.Parallel()
+
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	got, err := c.Ports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{10230, 20230, 30230}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestMikrusAssignsDomainToPort(t *testing.T) {
+	t.Parallel()
+
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	res, err := c.AssignDomain(20230, "app.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TaskID == "" {
+		t.Error("want task ID")
+	}
+	want := map[int]string{20230: "app.example.com"}
+	if got := ts.Handler.Domains("j230"); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if _, err := c.AssignDomain(443, "app.example.com"); err == nil {
+		t.Error("want error for port not assigned to the server, got nil")
+	}
+}
+
+func TestMikrusBoostsServer(t *testing.T) {
+	t.Parallel()
+
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	res, err := c.Boost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := ts.Handler.Logs("j230")
+	if logs[0].ID != res.TaskID || logs[0].Task != "amfetamina" {
+		t.Errorf("want amfetamina log entry %s, got %+v", res.TaskID, logs[0])
+	}
+}
+
+func TestMikrusRestartsServer(t *testing.T) {
+	t.Parallel()
+
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	res, err := c.Restart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := ts.Handler.Logs("j230")
+	if logs[0].ID != res.TaskID || logs[0].Task != "restart" {
+		t.Errorf("want restart log entry %s, got %+v", res.TaskID, logs[0])
+	}
+}
+
+func TestMikrusExecutesCommand(t *testing.T) {
+	t.Parallel()
+
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	got, err := c.Exec("hostname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "j230\n"; want != got {
+		t.Errorf("want output %q, got %q", want, got)
+	}
+	if _, err := c.Exec(" "); err == nil {
+		t.Error("want error for empty command, got nil")
+	}
+}
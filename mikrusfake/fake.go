@@ -0,0 +1,285 @@
+// Package mikrusfake provides an in-memory fake of the Mikrus API,
+// so code using mikrus.API can be tested without HTTP.
+//
+// The fake keeps state like the real API: tasks like restart or exec
+// add log entries, domains assigned to ports and uploaded SSH keys are
+// remembered. Errors of methods can be injected with Fail.
+package mikrusfake
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// timeLayout is the layout of dates returned by the Mikrus API.
+const timeLayout = "2006-01-02 15:04:05"
+
+// maxLogs is the number of log entries returned by Logs.
+const maxLogs = 10
+
+// Config describes the initial state of a fake server.
+type Config struct {
+	// Server is returned by Info.
+	Server mikrus.Server
+
+	// Servers are servers of the account. If nil, Servers returns
+	// only Server.
+	Servers mikrus.Servers
+
+	// Logs are the initial log entries, newest first.
+	Logs mikrus.Logs
+
+	// Stats are returned by Stats.
+	Stats mikrus.Stats
+
+	// Ports are TCP ports assigned to the server.
+	Ports []int
+
+	// Exec returns the output of commands. If nil, commands succeed
+	// without output. It must not call methods of the Server.
+	Exec func(command string) (string, error)
+}
+
+// Server is a fake Mikrus server. *Server implements mikrus.API and
+// is safe for concurrent use.
+type Server struct {
+	cfg Config
+
+	mu        sync.Mutex
+	logs      mikrus.Logs // oldest first
+	nextLogID int
+	domains   map[int]string
+	sshKeys   []string
+	calls     map[string]int
+	errs      map[string]error
+}
+
+var _ mikrus.API = (*Server)(nil)
+
+// New creates a fake server in the state described by cfg.
+func New(cfg Config) *Server {
+	s := &Server{
+		cfg:       cfg,
+		logs:      slices.Clone(cfg.Logs),
+		nextLogID: 1,
+		domains:   map[int]string{},
+		calls:     map[string]int{},
+		errs:      map[string]error{},
+	}
+	slices.Reverse(s.logs)
+	for _, l := range s.logs {
+		if id, err := strconv.Atoi(l.ID); err == nil && id >= s.nextLogID {
+			s.nextLogID = id + 1
+		}
+	}
+	return s
+}
+
+// Fail makes calls of the method, like "Info" or "Restart", return
+// err. A nil err makes the method work again.
+func (s *Server) Fail(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errs, method)
+		return
+	}
+	s.errs[method] = err
+}
+
+// Calls returns the number of calls of the method, like "Info"
+// or "Restart", including failed ones.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// Domains returns domains assigned to ports of the server.
+func (s *Server) Domains() map[int]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.domains)
+}
+
+// SSHKeys returns SSH public keys uploaded to the server.
+func (s *Server) SSHKeys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.sshKeys)
+}
+
+// call records the call of the method and returns its injected error.
+// It must be called with s.mu held.
+func (s *Server) call(method string) error {
+	s.calls[method]++
+	return s.errs[method]
+}
+
+// Info implements mikrus.API.
+func (s *Server) Info() (mikrus.Server, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Info"); err != nil {
+		return mikrus.Server{}, err
+	}
+	return s.cfg.Server, nil
+}
+
+// Servers implements mikrus.API.
+func (s *Server) Servers() (mikrus.Servers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Servers"); err != nil {
+		return nil, err
+	}
+	if s.cfg.Servers != nil {
+		return slices.Clone(s.cfg.Servers), nil
+	}
+	srv := s.cfg.Server
+	return mikrus.Servers{{
+		ServerID:   srv.ServerID,
+		ServerName: srv.ServerName,
+		Expires:    srv.Expires,
+		ParamRam:   srv.ParamRam,
+		ParamDisk:  srv.ParamDisk,
+	}}, nil
+}
+
+// Logs implements mikrus.API. It returns at most 10 newest log
+// entries, newest first.
+func (s *Server) Logs() (mikrus.Logs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Logs"); err != nil {
+		return nil, err
+	}
+	logs := make(mikrus.Logs, 0, min(maxLogs, len(s.logs)))
+	for i := len(s.logs) - 1; i >= 0 && len(logs) < maxLogs; i-- {
+		logs = append(logs, s.logs[i])
+	}
+	return logs, nil
+}
+
+// Stats implements mikrus.API.
+func (s *Server) Stats() (mikrus.Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Stats"); err != nil {
+		return mikrus.Stats{}, err
+	}
+	return s.cfg.Stats, nil
+}
+
+// Ports implements mikrus.API.
+func (s *Server) Ports() ([]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Ports"); err != nil {
+		return nil, err
+	}
+	return slices.Clone(s.cfg.Ports), nil
+}
+
+// AssignDomain implements mikrus.API. It fails for ports not assigned
+// to the server.
+func (s *Server) AssignDomain(port int, domain string) (mikrus.TaskResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("AssignDomain"); err != nil {
+		return mikrus.TaskResponse{}, err
+	}
+	if domain == "" {
+		return mikrus.TaskResponse{}, errors.New("domain is required")
+	}
+	if !slices.Contains(s.cfg.Ports, port) {
+		return mikrus.TaskResponse{}, fmt.Errorf("port %d is not assigned to the server", port)
+	}
+	s.domains[port] = domain
+	id := s.addLog("domain", fmt.Sprintf("Domena %s przypisana do portu %d\n", domain, port))
+	return mikrus.TaskResponse{TaskID: id, Message: "OK"}, nil
+}
+
+// Boost implements mikrus.API.
+func (s *Server) Boost() (mikrus.TaskResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Boost"); err != nil {
+		return mikrus.TaskResponse{}, err
+	}
+	id := s.addLog("amfetamina", "Amfetamina aktywna przez 30 minut\n")
+	return mikrus.TaskResponse{TaskID: id, Message: "Amfetamina włączona"}, nil
+}
+
+// Restart implements mikrus.API.
+func (s *Server) Restart() (mikrus.TaskResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Restart"); err != nil {
+		return mikrus.TaskResponse{}, err
+	}
+	id := s.addLog("restart", "OK\n")
+	return mikrus.TaskResponse{TaskID: id, Message: "Restart zlecony"}, nil
+}
+
+// Exec implements mikrus.API. Commands are run by Config.Exec.
+func (s *Server) Exec(command string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("Exec"); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("command is required")
+	}
+	var output string
+	if s.cfg.Exec != nil {
+		var err error
+		if output, err = s.cfg.Exec(command); err != nil {
+			return "", err
+		}
+	}
+	s.addLog("exec", output)
+	return output, nil
+}
+
+// UploadSSHKey implements mikrus.API. It fails for invalid keys.
+func (s *Server) UploadSSHKey(pubKey string) (mikrus.TaskResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.call("UploadSSHKey"); err != nil {
+		return mikrus.TaskResponse{}, err
+	}
+	key, err := mikrus.ParseSSHPublicKey(pubKey)
+	if err != nil {
+		return mikrus.TaskResponse{}, err
+	}
+	s.sshKeys = append(s.sshKeys, key.String())
+	id := s.addLog("kluczssh", "Wrzuciłem klucz SSH\n")
+	return mikrus.TaskResponse{TaskID: id, Message: "OK"}, nil
+}
+
+// addLog adds a log entry of a task done now and returns its ID.
+// It must be called with s.mu held.
+func (s *Server) addLog(task, output string) string {
+	now := time.Now().In(mikrus.Location).Format(timeLayout)
+	id := strconv.Itoa(s.nextLogID)
+	s.nextLogID++
+	s.logs = append(s.logs, mikrus.Log{
+		ID:          id,
+		ServerID:    s.cfg.Server.ServerID,
+		Task:        task,
+		WhenCreated: now,
+		WhenDone:    now,
+		Output:      output,
+	})
+	return id
+}
@@ -0,0 +1,150 @@
+package mikrusfake_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrusfake"
+)
+
+const testKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f jakub@laptop"
+
+func newServer() *mikrusfake.Server {
+	return mikrusfake.New(mikrusfake.Config{
+		Server: mikrus.Server{ServerID: "j230", Expires: "2026-06-08 00:00:00", ParamRam: "1024", ParamDisk: "10"},
+		Logs: mikrus.Logs{
+			{ID: "7", ServerID: "j230", Task: "restart", WhenCreated: "2024-06-05 10:02:55", WhenDone: "2024-06-05 10:03:10", Output: "OK\n"},
+		},
+		Ports: []int{10230, 20230},
+		Exec: func(command string) (string, error) {
+			if command == "hostname" {
+				return "j230\n", nil
+			}
+			return "", errors.New("unknown command")
+		},
+	})
+}
+
+func TestServer_ReturnsConfiguredState(t *testing.T) {
+	t.Parallel()
+	s := newServer()
+	info, err := s.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ServerID != "j230" {
+		t.Errorf("want server j230, got %s", info.ServerID)
+	}
+	servers, err := s.Servers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mikrus.Servers{{ServerID: "j230", Expires: "2026-06-08 00:00:00", ParamRam: "1024", ParamDisk: "10"}}
+	if !cmp.Equal(want, servers) {
+		t.Error(cmp.Diff(want, servers))
+	}
+	ports, err := s.Ports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal([]int{10230, 20230}, ports) {
+		t.Errorf("want configured ports, got %v", ports)
+	}
+}
+
+func TestServer_AddsLogsOfTasks(t *testing.T) {
+	t.Parallel()
+	s := newServer()
+	if _, err := s.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	boost, err := s.Boost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := s.Exec("hostname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "j230\n" {
+		t.Errorf("want hostname output, got %q", out)
+	}
+	if boost.TaskID != "9" {
+		t.Errorf("want boost task ID 9, got %q", boost.TaskID)
+	}
+	logs, err := s.Logs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tasks []string
+	for _, l := range logs {
+		tasks = append(tasks, l.ID+" "+l.Task)
+	}
+	want := []string{"10 exec", "9 amfetamina", "8 restart", "7 restart"}
+	if !cmp.Equal(want, tasks) {
+		t.Error(cmp.Diff(want, tasks))
+	}
+	if logs[0].Output != "j230\n" || logs[0].WhenDone == "" {
+		t.Errorf("want finished exec task with output, got %+v", logs[0])
+	}
+}
+
+func TestServer_ReturnsAtMostTenNewestLogs(t *testing.T) {
+	t.Parallel()
+	s := newServer()
+	for range 12 {
+		if _, err := s.Boost(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logs, err := s.Logs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 10 || logs[0].ID != "19" {
+		t.Errorf("want 10 newest logs, got %d starting with %q", len(logs), logs[0].ID)
+	}
+}
+
+func TestServer_RemembersDomainsAndSSHKeys(t *testing.T) {
+	t.Parallel()
+	s := newServer()
+	if _, err := s.AssignDomain(10230, "app.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AssignDomain(443, "app.example.com"); err == nil {
+		t.Error("want error for port not assigned to the server, got nil")
+	}
+	if _, err := s.UploadSSHKey(testKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadSSHKey("not a key"); err == nil {
+		t.Error("want error for invalid key, got nil")
+	}
+	if want, got := map[int]string{10230: "app.example.com"}, s.Domains(); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got := s.SSHKeys(); len(got) != 1 || !strings.HasPrefix(got[0], "ssh-ed25519 ") {
+		t.Errorf("want uploaded key, got %v", got)
+	}
+}
+
+func TestServer_ReturnsInjectedErrors(t *testing.T) {
+	t.Parallel()
+	s := newServer()
+	boom := errors.New("boom")
+	s.Fail("Stats", boom)
+	if _, err := s.Stats(); !errors.Is(err, boom) {
+		t.Errorf("want injected error, got %v", err)
+	}
+	s.Fail("Stats", nil)
+	if _, err := s.Stats(); err != nil {
+		t.Errorf("want no error after clearing it, got %v", err)
+	}
+	if got := s.Calls("Stats"); got != 2 {
+		t.Errorf("want 2 calls, got %d", got)
+	}
+}
@@ -0,0 +1,140 @@
+package mikrustest
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Scenario describes the state and behaviour of the mock Mikrus API.
+type Scenario struct {
+	// Latency is added to every response.
+	Latency time.Duration `yaml:"latency"`
+
+	// TaskDuration is the time it takes to complete tasks, like restart
+	// or exec. Log entries of unfinished tasks have empty WhenDone.
+	TaskDuration time.Duration `yaml:"taskDuration"`
+
+	// RateLimit limits the number of requests per API key.
+	RateLimit RateLimit `yaml:"rateLimit"`
+
+	// Errors are injected into responses for matching verbs.
+	Errors []ErrorRule `yaml:"errors"`
+
+	// Servers are Mikrus servers belonging to the account.
+	Servers []ServerConfig `yaml:"servers"`
+}
+
+// RateLimit allows Requests per API key in every Window.
+// Zero Requests disables rate limiting.
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+}
+
+// ErrorRule makes the mock API fail calls to the Verb.
+type ErrorRule struct {
+	// Verb is the API verb, like "stats" or "restart".
+	Verb string `yaml:"verb"`
+
+	// Status is the HTTP status code of the response.
+	Status int `yaml:"status"`
+
+	// Body is the response body.
+	Body string `yaml:"body"`
+
+	// Times is the number of calls that fail before the verb starts
+	// to work again. Zero means every call fails.
+	Times int `yaml:"times"`
+}
+
+// ServerConfig describes a single mock Mikrus server.
+type ServerConfig struct {
+	ID      string `yaml:"id"`
+	Name    string `yaml:"name"`
+	APIKey  string `yaml:"apiKey"`
+	Expires string `yaml:"expires"`
+	RAM     int    `yaml:"ram"`
+	Disk    int    `yaml:"disk"`
+	Pro     bool   `yaml:"pro"`
+	Ports   []int  `yaml:"ports"`
+
+	// Logs are the initial log entries, oldest first.
+	Logs []LogConfig `yaml:"logs"`
+
+	// Stats contain raw output of the free, df, uptime and ps
+	// commands. Missing outputs are generated from RAM and Disk.
+	Stats StatsConfig `yaml:"stats"`
+}
+
+// LogConfig describes a log entry of a mock server.
+type LogConfig struct {
+	ID          string `yaml:"id"`
+	Task        string `yaml:"task"`
+	WhenCreated string `yaml:"whenCreated"`
+	WhenDone    string `yaml:"whenDone"`
+	Output      string `yaml:"output"`
+}
+
+// StatsConfig contains raw output of commands returned by the stats verb.
+type StatsConfig struct {
+	Free   string `yaml:"free"`
+	DF     string `yaml:"df"`
+	Uptime string `yaml:"uptime"`
+	PS     string `yaml:"ps"`
+}
+
+// LoadScenario reads a scenario from a YAML file.
+func LoadScenario(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return Scenario{}, fmt.Errorf("parsing scenario %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// DefaultScenario returns a scenario with a single server "j230"
+// with API key "testKey", resembling a freshly upgraded Mikrus 2.0.
+func DefaultScenario() Scenario {
+	return Scenario{
+		Servers: []ServerConfig{
+			{
+				ID:      "j230",
+				APIKey:  "testKey",
+				Expires: "2026-06-08 00:00:00",
+				RAM:     1024,
+				Disk:    10,
+				Ports:   []int{10230, 20230, 30230},
+				Logs: []LogConfig{
+					{
+						ID:          "3748",
+						Task:        "upgrade",
+						WhenCreated: "2024-06-05 08:59:28",
+						WhenDone:    "2024-06-05 09:00:04",
+						Output:      "=== Aktualne parametry: 768 RAM / 10 DYSK\n2 / 20\nDodaje: +256MB RAM oraz +0GB dysku\nPo zmianie: 1024 MB / 10 GB\n[succes] GOTOWE!\n",
+					},
+					{
+						ID:          "3751",
+						Task:        "restart",
+						WhenCreated: "2024-06-05 09:57:54",
+						WhenDone:    "2024-06-05 09:58:07",
+						Output:      "OK\n",
+					},
+					{
+						ID:          "3752",
+						Task:        "kluczssh",
+						WhenCreated: "2024-06-05 10:05:34",
+						WhenDone:    "2024-06-05 10:06:01",
+						Output:      "Wrzuciłem klucz SSH\n",
+					},
+				},
+			},
+		},
+	}
+}
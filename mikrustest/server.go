@@ -0,0 +1,486 @@
+// Package mikrustest provides a mock Mikrus API server for tests
+// and offline development.
+//
+// The mock implements every Mikrus API verb and keeps state: tasks
+// like restart or exec add log entries, domains assigned to ports are
+// remembered, and API keys and server IDs are validated. Latency,
+// errors and rate limiting can be configured with a Scenario.
+package mikrustest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// timeLayout is the layout of dates returned by the Mikrus API.
+const timeLayout = "2006-01-02 15:04:05"
+
+// maxLogs is the number of log entries returned by the logs verb.
+const maxLogs = 10
+
+// Handler is an http.Handler implementing the Mikrus API.
+type Handler struct {
+	scenario Scenario
+
+	mu        sync.Mutex
+	servers   map[string]*server
+	order     []string
+	nextLogID int
+	calls     map[string]int
+	failures  map[int]int
+	windows   map[string]*rateWindow
+}
+
+type server struct {
+	config  ServerConfig
+	logs    []logEntry
+	domains map[int]string
+	sshKeys []string
+}
+
+type logEntry struct {
+	mikrus.Log
+	doneAt time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// NewHandler creates a mock Mikrus API handler for the scenario.
+func NewHandler(sc Scenario) (*Handler, error) {
+	h := &Handler{
+		scenario: sc,
+		servers:  map[string]*server{},
+		calls:    map[string]int{},
+		failures: map[int]int{},
+		windows:  map[string]*rateWindow{},
+	}
+	for _, cfg := range sc.Servers {
+		if cfg.ID == "" || cfg.APIKey == "" {
+			return nil, errors.New("server ID and API key are required")
+		}
+		if _, ok := h.servers[cfg.ID]; ok {
+			return nil, fmt.Errorf("duplicate server %q", cfg.ID)
+		}
+		srv := &server{config: cfg, domains: map[int]string{}}
+		for _, l := range cfg.Logs {
+			id, err := strconv.Atoi(l.ID)
+			if err != nil {
+				return nil, fmt.Errorf("server %q: invalid log ID %q", cfg.ID, l.ID)
+			}
+			h.nextLogID = max(h.nextLogID, id+1)
+			srv.logs = append(srv.logs, logEntry{Log: mikrus.Log{
+				ID:          l.ID,
+				ServerID:    cfg.ID,
+				Task:        l.Task,
+				WhenCreated: l.WhenCreated,
+				WhenDone:    l.WhenDone,
+				Output:      l.Output,
+			}})
+		}
+		h.servers[cfg.ID] = srv
+		h.order = append(h.order, cfg.ID)
+	}
+	if h.nextLogID == 0 {
+		h.nextLogID = 1
+	}
+	return h, nil
+}
+
+// Calls returns the number of requests received for the verb,
+// including rejected ones.
+func (h *Handler) Calls(verb string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls[verb]
+}
+
+// Logs returns all log entries of the server, newest first.
+func (h *Handler) Logs(srvID string) mikrus.Logs {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	srv, ok := h.servers[srvID]
+	if !ok {
+		return nil
+	}
+	return srv.recentLogs(len(srv.logs))
+}
+
+// Domains returns domains assigned to ports of the server.
+func (h *Handler) Domains(srvID string) map[int]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	srv, ok := h.servers[srvID]
+	if !ok {
+		return nil
+	}
+	domains := make(map[int]string, len(srv.domains))
+	for port, domain := range srv.domains {
+		domains[port] = domain
+	}
+	return domains
+}
+
+// SSHKeys returns SSH public keys uploaded to the server.
+func (h *Handler) SSHKeys(srvID string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	srv, ok := h.servers[srvID]
+	if !ok {
+		return nil
+	}
+	return slices.Clone(srv.sshKeys)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.scenario.Latency > 0 {
+		select {
+		case <-time.After(h.scenario.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	verb, arg, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls[verb]++
+
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "POST required")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	key, srvID := r.PostForm.Get("key"), r.PostForm.Get("srv")
+	if h.rateLimited(key) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(h.scenario.RateLimit.Window.Seconds())))
+		writeError(w, http.StatusTooManyRequests, "too many requests")
+		return
+	}
+	if h.injectError(w, verb) {
+		return
+	}
+	srv, ok := h.servers[srvID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown server")
+		return
+	}
+	if srv.config.APIKey != key {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+
+	switch verb {
+	case "info":
+		h.info(w, srv)
+	case "serwery":
+		h.serwery(w)
+	case "logs":
+		h.logs(w, srv, arg)
+	case "stats":
+		writeJSON(w, srv.stats())
+	case "porty":
+		writeJSON(w, srv.config.Ports)
+	case "cloud":
+		writeJSON(w, []any{})
+	case "db":
+		h.db(w, srv)
+	case "restart":
+		id := h.addLog(srv, "restart", "OK\n")
+		writeJSON(w, mikrus.TaskResponse{TaskID: id, Message: "Restart zlecony"})
+	case "amfetamina":
+		id := h.addLog(srv, "amfetamina", "Amfetamina aktywna przez 30 minut\n")
+		writeJSON(w, mikrus.TaskResponse{TaskID: id, Message: "Amfetamina włączona"})
+	case "exec":
+		h.exec(w, r, srv)
+	case "domain":
+		h.domain(w, r, srv)
+	case "kluczssh":
+		h.sshKey(w, r, srv)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown verb %q", verb))
+	}
+}
+
+func (h *Handler) info(w http.ResponseWriter, srv *server) {
+	cfg := srv.config
+	pro := "nie"
+	if cfg.Pro {
+		pro = "tak"
+	}
+	var lastLog string
+	if logs := srv.recentLogs(1); len(logs) > 0 {
+		lastLog = logs[0].WhenCreated
+	}
+	writeJSON(w, mikrus.Server{
+		ServerID:     cfg.ID,
+		ServerName:   cfg.Name,
+		Expires:      cfg.Expires,
+		ParamRam:     strconv.Itoa(cfg.RAM),
+		ParamDisk:    strconv.Itoa(cfg.Disk),
+		LastLogPanel: lastLog,
+		MikrusPro:    pro,
+	})
+}
+
+func (h *Handler) serwery(w http.ResponseWriter) {
+	servers := make(mikrus.Servers, 0, len(h.order))
+	for _, id := range h.order {
+		cfg := h.servers[id].config
+		servers = append(servers, mikrus.ServerShort{
+			ServerID:   cfg.ID,
+			ServerName: cfg.Name,
+			Expires:    cfg.Expires,
+			ParamRam:   strconv.Itoa(cfg.RAM),
+			ParamDisk:  strconv.Itoa(cfg.Disk),
+		})
+	}
+	writeJSON(w, servers)
+}
+
+func (h *Handler) logs(w http.ResponseWriter, srv *server, id string) {
+	if id == "" {
+		writeJSON(w, srv.recentLogs(maxLogs))
+		return
+	}
+	for _, l := range srv.recentLogs(len(srv.logs)) {
+		if l.ID == id {
+			writeJSON(w, l)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "unknown log entry")
+}
+
+func (h *Handler) db(w http.ResponseWriter, srv *server) {
+	id := srv.config.ID
+	writeJSON(w, map[string]string{
+		"postgres": fmt.Sprintf("Host: psql01.mikr.us\nLogin: %s\nHaslo: %s\nBaza: db_%s\n", id, dbPassword(srv), id),
+		"mysql":    fmt.Sprintf("Host: mysql.mikr.us\nLogin: %s\nHaslo: %s\nBaza: db_%s\n", id, dbPassword(srv), id),
+	})
+}
+
+// dbPassword returns a stable database password of the server.
+func dbPassword(srv *server) string {
+	return "P4ss" + strings.ToUpper(srv.config.ID)
+}
+
+func (h *Handler) exec(w http.ResponseWriter, r *http.Request, srv *server) {
+	cmd := r.PostForm.Get("cmd")
+	if strings.TrimSpace(cmd) == "" {
+		writeError(w, http.StatusBadRequest, "missing cmd parameter")
+		return
+	}
+	stats := srv.stats()
+	var output string
+	switch strings.Fields(cmd)[0] {
+	case "free":
+		output = stats.Free
+	case "df":
+		output = stats.DF
+	case "uptime":
+		output = stats.Uptime
+	case "ps":
+		output = stats.PS
+	case "echo":
+		output = strings.TrimSpace(strings.TrimPrefix(cmd, "echo")) + "\n"
+	case "hostname":
+		output = srv.config.ID + "\n"
+	default:
+		output = fmt.Sprintf("sh: 1: %s: not found\n", strings.Fields(cmd)[0])
+	}
+	h.addLog(srv, "exec", output)
+	writeJSON(w, map[string]string{"output": output})
+}
+
+func (h *Handler) domain(w http.ResponseWriter, r *http.Request, srv *server) {
+	port, err := strconv.Atoi(r.PostForm.Get("port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid port parameter")
+		return
+	}
+	domain := r.PostForm.Get("domain")
+	if domain == "" {
+		writeError(w, http.StatusBadRequest, "missing domain parameter")
+		return
+	}
+	if !slices.Contains(srv.config.Ports, port) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("port %d is not assigned to the server", port))
+		return
+	}
+	srv.domains[port] = domain
+	id := h.addLog(srv, "domain", fmt.Sprintf("Domena %s przypisana do portu %d\n", domain, port))
+	writeJSON(w, mikrus.TaskResponse{TaskID: id, Message: "OK"})
+}
+
+func (h *Handler) sshKey(w http.ResponseWriter, r *http.Request, srv *server) {
+	key := r.PostForm.Get("pubkey")
+	if _, err := mikrus.ParseSSHPublicKey(key); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid pubkey parameter")
+		return
+	}
+	srv.sshKeys = append(srv.sshKeys, key)
+	id := h.addLog(srv, "kluczssh", "Wrzuciłem klucz SSH\n")
+	writeJSON(w, mikrus.TaskResponse{TaskID: id, Message: "OK"})
+}
+
+// addLog adds a log entry for a task started now and returns its ID.
+func (h *Handler) addLog(srv *server, task, output string) string {
+	now := time.Now().In(mikrus.Location)
+	id := strconv.Itoa(h.nextLogID)
+	h.nextLogID++
+	srv.logs = append(srv.logs, logEntry{
+		Log: mikrus.Log{
+			ID:          id,
+			ServerID:    srv.config.ID,
+			Task:        task,
+			WhenCreated: now.Format(timeLayout),
+			Output:      output,
+		},
+		doneAt: now.Add(h.scenario.TaskDuration),
+	})
+	return id
+}
+
+// recentLogs returns at most n newest log entries, newest first.
+// Entries of tasks that have finished get their WhenDone set.
+func (s *server) recentLogs(n int) mikrus.Logs {
+	now := time.Now()
+	logs := make(mikrus.Logs, 0, min(n, len(s.logs)))
+	for i := len(s.logs) - 1; i >= 0 && len(logs) < n; i-- {
+		entry := &s.logs[i]
+		if entry.WhenDone == "" && !now.Before(entry.doneAt) {
+			entry.WhenDone = entry.doneAt.In(mikrus.Location).Format(timeLayout)
+		}
+		logs = append(logs, entry.Log)
+	}
+	return logs
+}
+
+// stats returns raw output of commands reported by the stats verb.
+func (s *server) stats() StatsConfig {
+	cfg := s.config
+	stats := cfg.Stats
+	if stats.Free == "" {
+		used := cfg.RAM / 10
+		stats.Free = fmt.Sprintf("               total        used        free      shared  buff/cache   available\nMem:           %d          %d         %d           0         %d         %d\nSwap:             0           0           0",
+			cfg.RAM, used, cfg.RAM-2*used, used, cfg.RAM-used)
+	}
+	if stats.DF == "" {
+		stats.DF = fmt.Sprintf("Filesystem                        Size  Used Avail Use%% Mounted on\n/dev/mapper/pve-vm--%[1]s--disk--0  %[2]dG  %[3]dG  %[4]dG  %[5]d%% /",
+			cfg.ID, cfg.Disk, cfg.Disk/4, cfg.Disk-cfg.Disk/4, 25)
+	}
+	if stats.Uptime == "" {
+		stats.Uptime = " 16:32:02 up 6 days,  8:33,  0 users,  load average: 0.00, 0.01, 0.05"
+	}
+	if stats.PS == "" {
+		stats.PS = "USER       PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND\n" +
+			"root         1  0.0  1.0 169412 10748 ?        Ss   Jun05   0:04 /sbin/init\n" +
+			"root        48  0.0  6.0 141148 63436 ?        Ss   Jun05   0:20 /lib/systemd/systemd-journald\n" +
+			"root       126  0.0  0.6  12172  7124 ?        Ss   Jun05   0:03 sshd: /usr/sbin/sshd -D [listener] 0 of 10-100 startups\n"
+	}
+	return stats
+}
+
+// rateLimited reports whether the request with the API key exceeds
+// the rate limit.
+func (h *Handler) rateLimited(key string) bool {
+	limit := h.scenario.RateLimit
+	if limit.Requests <= 0 {
+		return false
+	}
+	now := time.Now()
+	win, ok := h.windows[key]
+	if !ok || now.Sub(win.start) >= limit.Window {
+		win = &rateWindow{start: now}
+		h.windows[key] = win
+	}
+	win.count++
+	return win.count > limit.Requests
+}
+
+// injectError writes the error response configured for the verb
+// and reports whether it did so.
+func (h *Handler) injectError(w http.ResponseWriter, verb string) bool {
+	for i, rule := range h.scenario.Errors {
+		if rule.Verb != verb {
+			continue
+		}
+		if rule.Times > 0 && h.failures[i] >= rule.Times {
+			continue
+		}
+		h.failures[i]++
+		status := rule.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, rule.Body)
+		return true
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// Server is a mock Mikrus API listening on a local loopback address.
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+// NewServer starts and returns a new mock Mikrus API server.
+// It panics if the scenario is invalid. The caller should call
+// Close when finished, to shut it down.
+func NewServer(sc Scenario) *Server {
+	h, err := NewHandler(sc)
+	if err != nil {
+		panic("mikrustest: " + err.Error())
+	}
+	return &Server{
+		Server:  httptest.NewServer(h),
+		Handler: h,
+	}
+}
+
+// Client returns a Mikrus client for the server with the srvID,
+// configured to call the mock API.
+func (s *Server) Client(srvID string) mikrus.Client {
+	var key string
+	for _, cfg := range s.Handler.scenario.Servers {
+		if cfg.ID == srvID {
+			key = cfg.APIKey
+		}
+	}
+	c := mikrus.New(key, srvID)
+	c.URL = s.URL
+	c.HTTPClient = s.Server.Client()
+	return c
+}
@@ -0,0 +1,228 @@
+package mikrustest_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+func post(t *testing.T, ts *mikrustest.Server, verb string, form url.Values) *http.Response {
+	t.Helper()
+	resp, err := ts.Server.Client().PostForm(ts.URL+"/"+verb, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestServer_ReturnsServerInfo(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	got, err := c.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := mikrus.Server{
+		ServerID:     "j230",
+		Expires:      "2026-06-08 00:00:00",
+		ParamRam:     "1024",
+		ParamDisk:    "10",
+		LastLogPanel: "2024-06-05 10:05:34",
+		MikrusPro:    "nie",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestServer_ReturnsParsableStats(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	got, err := c.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Memory.Total != 1024 {
+		t.Errorf("want total memory 1024, got %d", got.Memory.Total)
+	}
+	if got.DiskSpace.Size != "10G" {
+		t.Errorf("want disk size 10G, got %q", got.DiskSpace.Size)
+	}
+	if len(got.Processes) != 3 {
+		t.Errorf("want 3 processes, got %d", len(got.Processes))
+	}
+}
+
+func TestServer_RejectsInvalidAPIKey(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	c := mikrus.New("bogus", "j230")
+	c.URL = ts.URL
+	c.HTTPClient = ts.Server.Client()
+	_, err := c.Info()
+	if err == nil {
+		t.Fatal("want error for invalid API key, got nil")
+	}
+	if !strings.Contains(err.Error(), "401") {
+		t.Errorf("want 401 status in error, got %v", err)
+	}
+}
+
+func TestServer_AddsLogEntriesForTasks(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	form := url.Values{"key": {"testKey"}, "srv": {"j230"}}
+	post(t, ts, "restart", form)
+	form.Set("cmd", "hostname")
+	post(t, ts, "exec", form)
+
+	c := ts.Client("j230")
+	logs, err := c.Logs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 5 {
+		t.Fatalf("want 5 log entries, got %d", len(logs))
+	}
+	if logs[0].ID != "3754" || logs[0].Task != "exec" || logs[0].Output != "j230\n" {
+		t.Errorf("want exec log entry 3754 first, got %+v", logs[0])
+	}
+	if logs[1].ID != "3753" || logs[1].Task != "restart" {
+		t.Errorf("want restart log entry 3753 second, got %+v", logs[1])
+	}
+	if logs[0].WhenDone == "" {
+		t.Error("want finished task without configured duration")
+	}
+}
+
+func TestServer_LeavesTasksPendingForTaskDuration(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.TaskDuration = time.Hour
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	post(t, ts, "restart", url.Values{"key": {"testKey"}, "srv": {"j230"}})
+	logs := ts.Handler.Logs("j230")
+	if logs[0].Task != "restart" || logs[0].WhenDone != "" {
+		t.Errorf("want pending restart log entry, got %+v", logs[0])
+	}
+}
+
+func TestServer_AssignsDomainsToServerPorts(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	form := url.Values{"key": {"testKey"}, "srv": {"j230"}, "port": {"20230"}, "domain": {"app.example.com"}}
+	if resp := post(t, ts, "domain", form); resp.StatusCode != http.StatusOK {
+		t.Fatalf("want status 200, got %d", resp.StatusCode)
+	}
+	form.Set("port", "80")
+	if resp := post(t, ts, "domain", form); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("want status 400 for port not assigned to the server, got %d", resp.StatusCode)
+	}
+	want := map[int]string{20230: "app.example.com"}
+	if got := ts.Handler.Domains("j230"); !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestServer_RejectsBlankCommands(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	for _, cmd := range []string{"", "   ", "\t\n"} {
+		form := url.Values{"key": {"testKey"}, "srv": {"j230"}, "cmd": {cmd}}
+		if resp := post(t, ts, "exec", form); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("cmd %q: want status 400, got %d", cmd, resp.StatusCode)
+		}
+	}
+}
+
+func TestServer_InjectsErrors(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Errors = []mikrustest.ErrorRule{{Verb: "info", Status: http.StatusServiceUnavailable, Times: 1}}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	if _, err := c.Info(); err == nil {
+		t.Fatal("want injected error, got nil")
+	}
+	if _, err := c.Info(); err != nil {
+		t.Fatalf("want success after injected errors, got %v", err)
+	}
+	if got := ts.Handler.Calls("info"); got != 2 {
+		t.Errorf("want 2 info calls, got %d", got)
+	}
+}
+
+func TestServer_LimitsRequestRate(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.RateLimit = mikrustest.RateLimit{Requests: 2, Window: time.Hour}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	form := url.Values{"key": {"testKey"}, "srv": {"j230"}}
+	for range 2 {
+		if resp := post(t, ts, "info", form); resp.StatusCode != http.StatusOK {
+			t.Fatalf("want status 200, got %d", resp.StatusCode)
+		}
+	}
+	resp := post(t, ts, "info", form)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("want status 429, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Retry-After") != "3600" {
+		t.Errorf("want Retry-After 3600, got %q", resp.Header.Get("Retry-After"))
+	}
+}
+
+func TestLoadScenario_ReadsYAMLFile(t *testing.T) {
+	t.Parallel()
+	sc, err := mikrustest.LoadScenario("testdata/scenario.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Latency != 10*time.Millisecond || sc.TaskDuration != time.Hour {
+		t.Errorf("want latency 10ms and task duration 1h, got %v and %v", sc.Latency, sc.TaskDuration)
+	}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	c := ts.Client("a135")
+	servers, err := c.Servers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servers) != 2 || servers[1].ServerID != "j230" {
+		t.Errorf("want servers a135 and j230, got %+v", servers)
+	}
+	if _, err := c.Stats(); err == nil {
+		t.Error("want injected stats error, got nil")
+	}
+	if _, err := c.Stats(); err != nil {
+		t.Errorf("want stats after injected error, got %v", err)
+	}
+}
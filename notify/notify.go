@@ -0,0 +1,298 @@
+// Package notify delivers notifications about Mikrus servers
+// through pluggable channels, like stdout, webhooks, chat services
+// (Slack, Discord, Telegram), e-mail or external commands.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Message represents a notification.
+type Message struct {
+	Title    string    `json:"title"`
+	Text     string    `json:"text"`
+	Severity string    `json:"severity,omitempty"`
+	ServerID string    `json:"server_id,omitempty"`
+	Time     time.Time `json:"time"`
+
+	// Server, Stats and Log hold data the message is about, if any.
+	// They are not delivered, but are available to templates.
+	Server *mikrus.Server `json:"-"`
+	Stats  *mikrus.Stats  `json:"-"`
+	Log    *mikrus.Log    `json:"-"`
+}
+
+// String implements stringer interface.
+func (m Message) String() string {
+	return fmt.Sprintf("%s %s: %s", m.Time.Format(time.RFC3339), m.Title, m.Text)
+}
+
+// Notifier delivers messages.
+type Notifier interface {
+	Notify(ctx context.Context, m Message) error
+}
+
+// Multi delivers messages with every notifier. It returns
+// errors of all notifiers that failed.
+type Multi []Notifier
+
+// Notify implements Notifier.
+func (n Multi) Notify(ctx context.Context, m Message) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Writer writes messages as lines to W.
+type Writer struct {
+	W io.Writer
+}
+
+// Notify implements Notifier.
+func (n Writer) Notify(ctx context.Context, m Message) error {
+	_, err := fmt.Fprintln(n.W, m)
+	return err
+}
+
+// Exec runs a command for every message. The message text is passed
+// on the standard input, other fields in MIKRUS_TITLE, MIKRUS_SEVERITY,
+// MIKRUS_SERVER_ID and MIKRUS_TIME environment variables.
+type Exec struct {
+	Command []string
+}
+
+// Notify implements Notifier.
+func (n Exec) Notify(ctx context.Context, m Message) error {
+	if len(n.Command) == 0 {
+		return errors.New("exec notifier: empty command")
+	}
+	cmd := exec.CommandContext(ctx, n.Command[0], n.Command[1:]...)
+	cmd.Stdin = strings.NewReader(m.Text)
+	cmd.Env = append(os.Environ(),
+		"MIKRUS_TITLE="+m.Title,
+		"MIKRUS_SEVERITY="+m.Severity,
+		"MIKRUS_SERVER_ID="+m.ServerID,
+		"MIKRUS_TIME="+m.Time.Format(time.RFC3339),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("exec notifier: %w: %s", err, out)
+	}
+	return nil
+}
+
+// SMTP sends messages by e-mail.
+type SMTP struct {
+	// Addr is the address of the SMTP server, like "smtp.example.com:587".
+	Addr string
+	From string
+	To   []string
+
+	// Username and Password are used for PLAIN authentication
+	// when Username is not empty.
+	Username string
+	Password string
+
+	// Timeout limits the time of a delivery when the context has
+	// no deadline. If zero, 30 seconds is used.
+	Timeout time.Duration
+}
+
+// Notify implements Notifier.
+func (n SMTP) Notify(ctx context.Context, m Message) error {
+	if err := n.send(ctx, m); err != nil {
+		return fmt.Errorf("smtp notifier: %w", err)
+	}
+	return nil
+}
+
+// send delivers the message like smtp.SendMail, but gives up when
+// the context is cancelled or the deadline passes.
+func (n SMTP) send(ctx context.Context, m Message) error {
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		timeout := n.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		deadline = time.Now().Add(timeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Closing the connection unblocks reads and writes in progress.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.Email(m)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// Email returns the message formatted as an e-mail.
+func (n SMTP) Email(m Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(n.From))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(strings.Join(n.To, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerValue(m.Title)))
+	fmt.Fprintf(&b, "Date: %s\r\n", m.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// headerValue replaces line breaks in s with spaces, so the value
+// can't end the header and inject others.
+func headerValue(s string) string {
+	return strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+// Config describes a notifier in a config file.
+type Config struct {
+	// Type is one of: stdout, webhook, slack, discord, telegram, smtp, exec.
+	Type string `yaml:"type"`
+
+	// URL is the webhook URL of webhook, slack and discord notifiers.
+	URL string `yaml:"url"`
+
+	// Secret signs webhook payloads, see Delivery.
+	Secret string `yaml:"secret"`
+
+	// Retries is the number of retries of failed HTTP deliveries.
+	Retries int `yaml:"retries"`
+
+	// Token and ChatID configure Telegram.
+	Token  string `yaml:"token"`
+	ChatID string `yaml:"chatID"`
+
+	// Addr, From, To, Username and Password configure SMTP.
+	Addr     string   `yaml:"addr"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+
+	// Command is the command run by the exec notifier.
+	Command []string `yaml:"command"`
+
+	// Template optionally renders messages before they are delivered.
+	Template Template `yaml:"template"`
+}
+
+// New creates a notifier described by the config.
+func New(cfg Config) (Notifier, error) {
+	n, err := newNotifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Template != (Template{}) {
+		if err := cfg.Template.Validate(); err != nil {
+			return nil, fmt.Errorf("%s notifier: %w", cfg.Type, err)
+		}
+		n = Templated{Notifier: n, Template: cfg.Template}
+	}
+	return n, nil
+}
+
+func newNotifier(cfg Config) (Notifier, error) {
+	delivery := Delivery{Secret: cfg.Secret, Retries: cfg.Retries}
+	switch cfg.Type {
+	case "stdout":
+		return Writer{W: os.Stdout}, nil
+	case "webhook", "slack", "discord":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("%s notifier: url is required", cfg.Type)
+		}
+		switch cfg.Type {
+		case "slack":
+			return &Slack{URL: cfg.URL, Delivery: delivery}, nil
+		case "discord":
+			return &Discord{URL: cfg.URL, Delivery: delivery}, nil
+		}
+		return &Webhook{URL: cfg.URL, Delivery: delivery}, nil
+	case "telegram":
+		if cfg.Token == "" || cfg.ChatID == "" {
+			return nil, errors.New("telegram notifier: token and chatID are required")
+		}
+		return &Telegram{Token: cfg.Token, ChatID: cfg.ChatID, APIURL: cfg.URL, Delivery: delivery}, nil
+	case "smtp":
+		if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, errors.New("smtp notifier: addr, from and to are required")
+		}
+		return SMTP{
+			Addr:     cfg.Addr,
+			From:     cfg.From,
+			To:       cfg.To,
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}, nil
+	case "exec":
+		if len(cfg.Command) == 0 {
+			return nil, errors.New("exec notifier: command is required")
+		}
+		return Exec{Command: cfg.Command}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,232 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qba73/mikrus/notify"
+)
+
+var testMessage = notify.Message{
+	Title:    "[firing] disk-full on j230",
+	Text:     "disk_percent > 85 (current value 91.00)",
+	Severity: "critical",
+	ServerID: "j230",
+	Time:     time.Date(2024, time.June, 5, 10, 0, 0, 0, time.UTC),
+}
+
+func TestWriter_WritesMessageLine(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	if err := (notify.Writer{W: &b}).Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-06-05T10:00:00Z [firing] disk-full on j230: disk_percent > 85 (current value 91.00)\n"
+	if want != b.String() {
+		t.Errorf("want %q, got %q", want, b.String())
+	}
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(context.Context, notify.Message) error {
+	return errors.New("boom")
+}
+
+func TestMulti_NotifiesAllAndReturnsErrors(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	n := notify.Multi{failingNotifier{}, notify.Writer{W: &b}}
+	err := n.Notify(context.Background(), testMessage)
+	if err == nil {
+		t.Fatal("want error from failing notifier, got nil")
+	}
+	if b.Len() == 0 {
+		t.Error("want message delivered by remaining notifiers")
+	}
+}
+
+func TestExec_PassesMessageToCommand(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	n := notify.Exec{Command: []string{"sh", "-c", `printf '%s|' "$MIKRUS_SERVER_ID" > "$0"; cat >> "$0"`, out}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "j230|disk_percent > 85 (current value 91.00)"
+	if want != string(got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestSMTP_FormatsEmail(t *testing.T) {
+	t.Parallel()
+	n := notify.SMTP{From: "mikctl@example.com", To: []string{"ops@example.com", "dev@example.com"}}
+	got := string(n.Email(testMessage))
+	for _, want := range []string{
+		"From: mikctl@example.com\r\n",
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [firing] disk-full on j230\r\n",
+		"\r\n\r\ndisk_percent > 85 (current value 91.00)\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want e-mail to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestSMTP_RemovesLineBreaksFromHeaders(t *testing.T) {
+	t.Parallel()
+	n := notify.SMTP{From: "mikctl@example.com\r\nBcc: evil@example.com", To: []string{"ops@example.com"}}
+	m := testMessage
+	m.Title = "disk-full on j230\nBcc: evil@example.com"
+	got := string(n.Email(m))
+	if strings.Contains(got, "\r\nBcc:") || strings.Contains(got, "\nBcc:") {
+		t.Errorf("want no injected headers, got:\n%s", got)
+	}
+	for _, want := range []string{
+		"From: mikctl@example.com Bcc: evil@example.com\r\n",
+		"Subject: disk-full on j230 Bcc: evil@example.com\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want e-mail to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestSMTP_EncodesNonASCIISubject(t *testing.T) {
+	t.Parallel()
+	m := testMessage
+	m.Title = "dysk pełny"
+	got := string(notify.SMTP{}.Email(m))
+	if want := "Subject: =?utf-8?q?dysk_pe=C5=82ny?=\r\n"; !strings.Contains(got, want) {
+		t.Errorf("want e-mail to contain %q, got:\n%s", want, got)
+	}
+}
+
+// serveSMTP accepts a single SMTP session on a local listener and
+// sends the received e-mail to the returned channel.
+func serveSMTP(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	mail := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				mail <- data.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return l.Addr().String(), mail
+}
+
+func TestSMTP_DeliversEmail(t *testing.T) {
+	t.Parallel()
+	addr, mail := serveSMTP(t)
+	n := notify.SMTP{Addr: addr, From: "mikctl@example.com", To: []string{"ops@example.com"}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-mail; !strings.Contains(got, "Subject: [firing] disk-full on j230\r\n") {
+		t.Errorf("want delivered e-mail, got:\n%s", got)
+	}
+}
+
+func TestSMTP_GivesUpWhenContextIsDone(t *testing.T) {
+	t.Parallel()
+	// The server accepts connections, but never greets the client.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	n := notify.SMTP{Addr: l.Addr().String(), From: "mikctl@example.com", To: []string{"ops@example.com"}}
+	done := make(chan error, 1)
+	go func() { done <- n.Notify(ctx, testMessage) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("want error from hung server, got nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifier blocked on hung server")
+	}
+}
+
+func TestNew_ErrorsForInvalidConfig(t *testing.T) {
+	t.Parallel()
+	for _, cfg := range []notify.Config{
+		{Type: "bogus"},
+		{Type: "webhook"},
+		{Type: "slack"},
+		{Type: "discord"},
+		{Type: "telegram", Token: "123:abc"},
+		{Type: "smtp", Addr: "localhost:25"},
+		{Type: "webhook", URL: "http://localhost", Template: notify.Template{Title: "{{ .Bogus"}},
+		{Type: "exec"},
+	} {
+		if _, err := notify.New(cfg); err == nil {
+			t.Errorf("want error for %+v, got nil", cfg)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Data holds values available to message templates. Server, Stats
+// and Log are nil when the message is not about them; templates used
+// for different kinds of messages can guard them with `with`.
+type Data struct {
+	Message Message
+	Server  *mikrus.Server
+	Stats   *mikrus.Stats
+	Log     *mikrus.Log
+}
+
+// Template renders messages with text/template. Empty fields keep
+// values of Data.Message.
+type Template struct {
+	Title    string `yaml:"title"`
+	Text     string `yaml:"text"`
+	Severity string `yaml:"severity"`
+}
+
+var templateFuncs = template.FuncMap{
+	"oneline": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	"upper":   strings.ToUpper,
+}
+
+// Predefined templates for task completion, server statistics
+// and server information.
+var (
+	TaskDone = Template{
+		Title: "Task {{ .Log.Task }} finished on {{ .Log.ServerID }}",
+		Text:  "Task {{ .Log.ID }} created {{ .Log.WhenCreated }}, done {{ .Log.WhenDone }}: {{ .Log.Result }}",
+	}
+	StatsSummary = Template{
+		Title: "Stats of {{ .Message.ServerID }}",
+		Text: "Memory: {{ .Stats.Memory.Used }}/{{ .Stats.Memory.Total }} MB used, {{ .Stats.Memory.Available }} MB available\n" +
+			"Disk: {{ .Stats.DiskSpace.Used }}/{{ .Stats.DiskSpace.Size }} used ({{ .Stats.DiskSpace.Usage }})\n" +
+			"Load: {{ .Stats.Uptime.CPUload1min }} {{ .Stats.Uptime.CPUload5min }} {{ .Stats.Uptime.CPUload15min }}\n" +
+			"Processes: {{ len .Stats.Processes }}",
+	}
+	ServerInfo = Template{
+		Title: "Server {{ .Server.ServerID }} {{ .Server.ServerName }}",
+		Text:  "Expires {{ .Server.Expires }}, RAM {{ .Server.ParamRam }} MB, disk {{ .Server.ParamDisk }} GB",
+	}
+)
+
+// Render executes the template with the data and returns the resulting
+// message. Server, Stats and Log default to those carried by the
+// message and are carried on by the result. ServerID is taken from
+// Server or Log when not set in the message, and Time defaults to
+// the current time.
+func (t Template) Render(d Data) (Message, error) {
+	if d.Server == nil {
+		d.Server = d.Message.Server
+	}
+	if d.Stats == nil {
+		d.Stats = d.Message.Stats
+	}
+	if d.Log == nil {
+		d.Log = d.Message.Log
+	}
+	d.Message.Server, d.Message.Stats, d.Message.Log = d.Server, d.Stats, d.Log
+	m := d.Message
+	if m.ServerID == "" {
+		switch {
+		case d.Server != nil:
+			m.ServerID = d.Server.ServerID
+		case d.Log != nil:
+			m.ServerID = d.Log.ServerID
+		}
+		d.Message.ServerID = m.ServerID
+	}
+	if m.Time.IsZero() {
+		m.Time = time.Now()
+		d.Message.Time = m.Time
+	}
+	fields := []struct {
+		name string
+		text string
+		dst  *string
+	}{
+		{"title", t.Title, &m.Title},
+		{"text", t.Text, &m.Text},
+		{"severity", t.Severity, &m.Severity},
+	}
+	for _, f := range fields {
+		if f.text == "" {
+			continue
+		}
+		out, err := execute(f.name, f.text, d)
+		if err != nil {
+			return Message{}, err
+		}
+		*f.dst = out
+	}
+	return m, nil
+}
+
+// Validate reports whether all fields of the template parse.
+func (t Template) Validate() error {
+	for name, text := range map[string]string{"title": t.Title, "text": t.Text, "severity": t.Severity} {
+		if _, err := parse(name, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parse(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func execute(name, text string, d Data) (string, error) {
+	tmpl, err := parse(name, text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, d); err != nil {
+		return "", fmt.Errorf("rendering %s template: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Templated renders every message with the template before
+// delivering it with the notifier. Server, Stats and Log of the
+// message are available to the template.
+type Templated struct {
+	Notifier Notifier
+	Template Template
+}
+
+// Notify implements Notifier.
+func (n Templated) Notify(ctx context.Context, m Message) error {
+	msg, err := n.Template.Render(Data{Message: m})
+	if err != nil {
+		return err
+	}
+	return n.Notifier.Notify(ctx, msg)
+}
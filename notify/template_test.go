@@ -0,0 +1,129 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/notify"
+)
+
+func TestTaskDone_RendersLogEntry(t *testing.T) {
+	t.Parallel()
+	l := mikrus.Log{
+		ID:          "3748",
+		ServerID:    "j230",
+		Task:        "restart",
+		WhenCreated: "2024-06-05 10:00:00",
+		WhenDone:    "2024-06-05 10:00:12",
+		Output:      "Server restarted\n  OK",
+	}
+	got, err := notify.TaskDone.Render(notify.Data{Message: notify.Message{Time: testMessage.Time}, Log: &l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := notify.Message{
+		Title:    "Task restart finished on j230",
+		Text:     "Task 3748 created 2024-06-05 10:00:00, done 2024-06-05 10:00:12: done: Server restarted OK",
+		ServerID: "j230",
+		Time:     testMessage.Time,
+		Log:      &l,
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestStatsSummary_RendersStats(t *testing.T) {
+	t.Parallel()
+	stats := mikrus.Stats{
+		Memory:    mikrus.Memory{Total: 1024, Used: 512, Available: 400},
+		DiskSpace: mikrus.DiskSpace{Size: "9.8G", Used: "4.1G", Usage: "44%"},
+		Uptime:    mikrus.Uptime{CPUload1min: 0.5, CPUload5min: 0.25, CPUload15min: 0.1},
+		Processes: mikrus.Processes{{PID: 1}, {PID: 2}},
+	}
+	got, err := notify.StatsSummary.Render(notify.Data{Message: notify.Message{ServerID: "j230"}, Stats: &stats})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Memory: 512/1024 MB used, 400 MB available\n" +
+		"Disk: 4.1G/9.8G used (44%)\n" +
+		"Load: 0.5 0.25 0.1\n" +
+		"Processes: 2"
+	if got.Text != want {
+		t.Errorf("want text\n%s\ngot\n%s", want, got.Text)
+	}
+	if got.Title != "Stats of j230" {
+		t.Errorf("want title %q, got %q", "Stats of j230", got.Title)
+	}
+	if got.Time.IsZero() {
+		t.Error("want time set")
+	}
+}
+
+func TestServerInfo_TakesServerIDFromServer(t *testing.T) {
+	t.Parallel()
+	s := mikrus.Server{ServerID: "j230", ServerName: "web", Expires: "2025-01-01 00:00:00", ParamRam: "1024", ParamDisk: "10"}
+	got, err := notify.ServerInfo.Render(notify.Data{Server: &s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ServerID != "j230" {
+		t.Errorf("want server ID j230, got %q", got.ServerID)
+	}
+	want := "Expires 2025-01-01 00:00:00, RAM 1024 MB, disk 10 GB"
+	if got.Text != want {
+		t.Errorf("want %q, got %q", want, got.Text)
+	}
+}
+
+func TestRender_ErrorsForMissingData(t *testing.T) {
+	t.Parallel()
+	if _, err := notify.TaskDone.Render(notify.Data{}); err == nil {
+		t.Fatal("want error rendering log template without log, got nil")
+	}
+}
+
+type recorder struct {
+	got []notify.Message
+}
+
+func (r *recorder) Notify(ctx context.Context, m notify.Message) error {
+	r.got = append(r.got, m)
+	return nil
+}
+
+func TestTemplated_RendersMessageBeforeDelivery(t *testing.T) {
+	t.Parallel()
+	r := &recorder{}
+	n := notify.Templated{
+		Notifier: r,
+		Template: notify.Template{Title: "{{ .Message.Severity | upper }}: {{ .Message.Title }}"},
+	}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	want := testMessage
+	want.Title = "CRITICAL: [firing] disk-full on j230"
+	if !cmp.Equal([]notify.Message{want}, r.got) {
+		t.Error(cmp.Diff([]notify.Message{want}, r.got))
+	}
+}
+
+func TestTemplated_RendersDataCarriedByMessage(t *testing.T) {
+	t.Parallel()
+	r := &recorder{}
+	n := notify.Templated{
+		Notifier: r,
+		Template: notify.Template{Text: "{{ .Message.Text }}{{ with .Stats }}, load {{ .Uptime.CPUload1min }}{{ end }}{{ with .Log }}, task {{ .Task }}{{ end }}"},
+	}
+	m := testMessage
+	m.Stats = &mikrus.Stats{Uptime: mikrus.Uptime{CPUload1min: 0.5}}
+	if err := n.Notify(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+	if want := "disk_percent > 85 (current value 91.00), load 0.5"; r.got[0].Text != want {
+		t.Errorf("want %q, got %q", want, r.got[0].Text)
+	}
+}
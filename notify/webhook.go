@@ -0,0 +1,216 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header holding the HMAC-SHA256 signature
+// of signed payloads, formatted as "sha256=<hex digest>".
+const SignatureHeader = "X-Mikrus-Signature"
+
+// Delivery configures how notifications are posted over HTTP.
+type Delivery struct {
+	// HTTPClient is used to post notifications. If nil, a client
+	// with a 10 seconds timeout is used.
+	HTTPClient *http.Client
+
+	// Secret signs payloads with HMAC-SHA256 in the SignatureHeader
+	// when not empty.
+	Secret string
+
+	// Retries is the number of retries of failed deliveries.
+	// Deliveries are retried on network errors, 5xx and 429 responses.
+	Retries int
+
+	// Backoff is the delay before the first retry, doubled for every
+	// next one. If zero, one second is used.
+	Backoff time.Duration
+}
+
+// Sign returns the signature of the payload for the SignatureHeader.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends the payload as JSON to the URL, retrying failures.
+func (d Delivery) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := d.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	backoff := d.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		wait, err := d.send(ctx, client, url, body)
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= d.Retries {
+			return err
+		}
+		if wait <= 0 {
+			wait = backoff << attempt
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// permanentError is returned for deliveries that must not be retried.
+type permanentError struct {
+	error
+}
+
+// send makes a single delivery attempt. It returns the delay requested
+// by the server with the Retry-After header, if any.
+func (d Delivery) send(ctx context.Context, client *http.Client, url string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(d.Secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return 0, nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("unexpected response status %d: %q", resp.StatusCode, data)
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, permanentError{err}
+	}
+	seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return time.Duration(seconds) * time.Second, err
+}
+
+// Webhook posts messages as JSON to the URL.
+type Webhook struct {
+	URL string
+	Delivery
+}
+
+// Notify implements Notifier.
+func (n *Webhook) Notify(ctx context.Context, m Message) error {
+	if err := n.post(ctx, n.URL, m); err != nil {
+		return fmt.Errorf("webhook notifier: %w", err)
+	}
+	return nil
+}
+
+// Slack posts messages to a Slack incoming webhook URL.
+type Slack struct {
+	URL string
+	Delivery
+}
+
+// Notify implements Notifier.
+func (n *Slack) Notify(ctx context.Context, m Message) error {
+	payload := map[string]string{"text": fmt.Sprintf("*%s*\n%s", m.Title, m.Text)}
+	if err := n.post(ctx, n.URL, payload); err != nil {
+		return fmt.Errorf("slack notifier: %w", err)
+	}
+	return nil
+}
+
+// Discord posts messages to a Discord webhook URL as embeds
+// colored by the message severity.
+type Discord struct {
+	URL string
+	Delivery
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+	Timestamp   string `json:"timestamp,omitempty"`
+}
+
+// Notify implements Notifier.
+func (n *Discord) Notify(ctx context.Context, m Message) error {
+	embed := discordEmbed{
+		Title:       m.Title,
+		Description: m.Text,
+		Color:       severityColor(m.Severity),
+	}
+	if !m.Time.IsZero() {
+		embed.Timestamp = m.Time.Format(time.RFC3339)
+	}
+	payload := map[string][]discordEmbed{"embeds": {embed}}
+	if err := n.post(ctx, n.URL, payload); err != nil {
+		return fmt.Errorf("discord notifier: %w", err)
+	}
+	return nil
+}
+
+func severityColor(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical", "error":
+		return 0xd32f2f
+	case "warning":
+		return 0xf9a825
+	default:
+		return 0x1976d2
+	}
+}
+
+// TelegramAPI is the URL of the Telegram Bot API.
+const TelegramAPI = "https://api.telegram.org"
+
+// Telegram sends messages to a chat with the Telegram Bot API.
+type Telegram struct {
+	Token  string
+	ChatID string
+
+	// APIURL is the Bot API URL. If empty, TelegramAPI is used.
+	APIURL string
+
+	Delivery
+}
+
+// Notify implements Notifier.
+func (n *Telegram) Notify(ctx context.Context, m Message) error {
+	api := n.APIURL
+	if api == "" {
+		api = TelegramAPI
+	}
+	payload := map[string]string{
+		"chat_id": n.ChatID,
+		"text":    m.Title + "\n" + m.Text,
+	}
+	if err := n.post(ctx, api+"/bot"+n.Token+"/sendMessage", payload); err != nil {
+		// Errors may include the request URL holding the bot token.
+		return fmt.Errorf("telegram notifier: %s", strings.ReplaceAll(err.Error(), n.Token, "REDACTED"))
+	}
+	return nil
+}
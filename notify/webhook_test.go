@@ -0,0 +1,231 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus/notify"
+)
+
+// receiver starts a server decoding JSON payloads into got.
+func receiver(t *testing.T, got any) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("want JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestWebhook_PostsMessageAsJSON(t *testing.T) {
+	t.Parallel()
+	var got notify.Message
+	ts := receiver(t, &got)
+
+	n := &notify.Webhook{URL: ts.URL, Delivery: notify.Delivery{HTTPClient: ts.Client()}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(testMessage, got) {
+		t.Error(cmp.Diff(testMessage, got))
+	}
+}
+
+func TestWebhook_ErrorsOnUnexpectedStatus(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	n := &notify.Webhook{URL: ts.URL, Delivery: notify.Delivery{HTTPClient: ts.Client()}}
+	if err := n.Notify(context.Background(), testMessage); err == nil {
+		t.Fatal("want error for status 403, got nil")
+	}
+}
+
+func TestWebhook_SignsPayloadWithSecret(t *testing.T) {
+	t.Parallel()
+	var body []byte
+	var signature string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get(notify.SignatureHeader)
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer ts.Close()
+
+	n := &notify.Webhook{URL: ts.URL, Delivery: notify.Delivery{HTTPClient: ts.Client(), Secret: "s3cret"}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if want := notify.Sign("s3cret", body); signature != want {
+		t.Errorf("want signature %q, got %q", want, signature)
+	}
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Errorf("want sha256= prefix, got %q", signature)
+	}
+}
+
+func TestSign_ReturnsKnownDigest(t *testing.T) {
+	t.Parallel()
+	// echo -n 'hello' | openssl dgst -sha256 -hmac key
+	want := "sha256=9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b"
+	if got := notify.Sign("key", []byte("hello")); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestWebhook_RetriesServerErrors(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	n := &notify.Webhook{URL: ts.URL, Delivery: notify.Delivery{
+		HTTPClient: ts.Client(),
+		Retries:    2,
+		Backoff:    time.Millisecond,
+	}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("want 3 calls, got %d", got)
+	}
+}
+
+func TestWebhook_GivesUpAfterRetries(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	n := &notify.Webhook{URL: ts.URL, Delivery: notify.Delivery{
+		HTTPClient: ts.Client(),
+		Retries:    1,
+		Backoff:    time.Millisecond,
+	}}
+	if err := n.Notify(context.Background(), testMessage); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("want 2 calls, got %d", got)
+	}
+}
+
+func TestWebhook_DoesNotRetryClientErrors(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	n := &notify.Webhook{URL: ts.URL, Delivery: notify.Delivery{
+		HTTPClient: ts.Client(),
+		Retries:    3,
+		Backoff:    time.Millisecond,
+	}}
+	if err := n.Notify(context.Background(), testMessage); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("want 1 call, got %d", got)
+	}
+}
+
+func TestSlack_PostsTextPayload(t *testing.T) {
+	t.Parallel()
+	var got map[string]string
+	ts := receiver(t, &got)
+
+	n := &notify.Slack{URL: ts.URL, Delivery: notify.Delivery{HTTPClient: ts.Client()}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"text": "*[firing] disk-full on j230*\ndisk_percent > 85 (current value 91.00)"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestDiscord_PostsEmbedColoredBySeverity(t *testing.T) {
+	t.Parallel()
+	var got map[string][]map[string]any
+	ts := receiver(t, &got)
+
+	n := &notify.Discord{URL: ts.URL, Delivery: notify.Delivery{HTTPClient: ts.Client()}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]map[string]any{"embeds": {{
+		"title":       "[firing] disk-full on j230",
+		"description": "disk_percent > 85 (current value 91.00)",
+		"color":       float64(0xd32f2f),
+		"timestamp":   "2024-06-05T10:00:00Z",
+	}}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTelegram_SendsMessageToChat(t *testing.T) {
+	t.Parallel()
+	var path string
+	var got map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer ts.Close()
+
+	n := &notify.Telegram{Token: "123:abc", ChatID: "-100", APIURL: ts.URL, Delivery: notify.Delivery{HTTPClient: ts.Client()}}
+	if err := n.Notify(context.Background(), testMessage); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("want path /bot123:abc/sendMessage, got %q", path)
+	}
+	want := map[string]string{
+		"chat_id": "-100",
+		"text":    "[firing] disk-full on j230\ndisk_percent > 85 (current value 91.00)",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestTelegram_ErrorDoesNotContainToken(t *testing.T) {
+	t.Parallel()
+	n := &notify.Telegram{Token: "123:abc", ChatID: "-100", APIURL: "http://127.0.0.1:1"}
+	err := n.Notify(context.Background(), testMessage)
+	if err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if strings.Contains(err.Error(), "123:abc") {
+		t.Errorf("error contains bot token: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package mikrus
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Call describes a single HTTP round trip to the Mikrus API. Calls
+// served from the cache or shared with concurrent callers are not
+// round trips, so they are not reported.
+type Call struct {
+	Verb     string
+	ServerID string
+
+	// Status is the HTTP status code of the response, or zero when
+	// the request failed before a response was received.
+	Status   int
+	Duration time.Duration
+
+	// Size is the size of the response body in bytes.
+	Size int
+
+	// Err is the error returned by the call, if any.
+	Err error
+}
+
+// Metrics receives Calls made by a Client, for example to count calls,
+// errors and latency per verb. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	ObserveCall(Call)
+}
+
+// MetricsFunc adapts a function to the Metrics interface.
+type MetricsFunc func(Call)
+
+// ObserveCall implements Metrics.
+func (f MetricsFunc) ObserveCall(c Call) {
+	f(c)
+}
+
+// startCall starts the span of the API call of the verb.
+func (c *Client) startCall(verb string) trace.Span {
+	if c.Tracer == nil {
+		return nil
+	}
+	_, span := c.Tracer.Start(context.Background(), "mikrus "+verb,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("mikrus.verb", verb),
+			attribute.String("mikrus.server_id", c.serverID),
+		),
+	)
+	return span
+}
+
+// endCall reports the finished API call to the span, the logger and
+// the metrics of the client. The API key is sent in the request body,
+// so it is never part of the reported URL or errors.
+func (c *Client) endCall(span trace.Span, call Call) {
+	if span != nil {
+		if call.Status != 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", call.Status))
+		}
+		span.SetAttributes(attribute.Int("http.response.body.size", call.Size))
+		if call.Err != nil {
+			span.RecordError(call.Err)
+			span.SetStatus(codes.Error, call.Err.Error())
+		}
+		span.End()
+	}
+	if c.Logger != nil {
+		attrs := []slog.Attr{
+			slog.String("verb", call.Verb),
+			slog.String("server", call.ServerID),
+			slog.Int("status", call.Status),
+			slog.Duration("duration", call.Duration),
+			slog.Int("size", call.Size),
+		}
+		if call.Err != nil {
+			attrs = append(attrs, slog.String("error", call.Err.Error()))
+		}
+		c.Logger.LogAttrs(context.Background(), slog.LevelDebug, "mikrus api call", attrs...)
+	}
+	if c.Metrics != nil {
+		c.Metrics.ObserveCall(call)
+	}
+}
+
+// logCacheHit logs the response of the verb served from the cache.
+func (c *Client) logCacheHit(verb string) {
+	if c.Logger != nil {
+		c.Logger.LogAttrs(context.Background(), slog.LevelDebug, "mikrus api cache hit",
+			slog.String("verb", verb),
+			slog.String("server", c.serverID),
+		)
+	}
+}
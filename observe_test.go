@@ -0,0 +1,118 @@
+package mikrus_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClient_LogsCallsWithoutAPIKey(t *testing.T) {
+	t.Parallel()
+	ts := mikrustest.NewServer(mikrustest.DefaultScenario())
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	c := ts.Client("j230")
+	c.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c.Cache = mikrus.NewMemoryCache()
+	for range 2 {
+		if _, err := c.Info(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`msg="mikrus api call" verb=info server=j230 status=200 duration=`,
+		`msg="mikrus api cache hit" verb=info server=j230`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want log to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "testKey") {
+		t.Errorf("want log without API key, got:\n%s", got)
+	}
+}
+
+func TestClient_ReportsCallsToMetrics(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Errors = []mikrustest.ErrorRule{{Verb: "stats", Status: http.StatusInternalServerError, Body: "boom"}}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var calls []mikrus.Call
+	c := ts.Client("j230")
+	c.Metrics = mikrus.MetricsFunc(func(call mikrus.Call) {
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+	})
+	if _, err := c.Info(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Stats(); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	if len(calls) != 2 {
+		t.Fatalf("want 2 calls, got %+v", calls)
+	}
+	info, stats := calls[0], calls[1]
+	if info.Verb != "info" || info.ServerID != "j230" || info.Status != 200 || info.Size == 0 || info.Duration <= 0 || info.Err != nil {
+		t.Errorf("want successful info call, got %+v", info)
+	}
+	if stats.Verb != "stats" || stats.Status != 500 || stats.Size != len("boom") || stats.Err == nil {
+		t.Errorf("want failed stats call, got %+v", stats)
+	}
+}
+
+func TestClient_TracesCalls(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Errors = []mikrustest.ErrorRule{{Verb: "logs", Status: http.StatusBadGateway}}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	c := ts.Client("j230")
+	c.Tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("mikrus")
+	if _, err := c.Info(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Logs(); err == nil {
+		t.Fatal("want error, got nil")
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("want 2 spans, got %d", len(spans))
+	}
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name())
+	}
+	if want := []string{"mikrus info", "mikrus logs"}; !cmp.Equal(want, names) {
+		t.Error(cmp.Diff(want, names))
+	}
+	attrs := attribute.NewSet(spans[0].Attributes()...)
+	if v, _ := attrs.Value("http.response.status_code"); v.AsInt64() != 200 {
+		t.Errorf("want status code 200, got %v", v.Emit())
+	}
+	if v, _ := attrs.Value("mikrus.server_id"); v.AsString() != "j230" {
+		t.Errorf("want server ID j230, got %v", v.Emit())
+	}
+	if got := spans[1].Status().Code; got != codes.Error {
+		t.Errorf("want error status of failed call, got %v", got)
+	}
+}
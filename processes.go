@@ -0,0 +1,228 @@
+package mikrus
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Processes represents a list of processes running on a server.
+type Processes []ProcessInfo
+
+// String implements stringer interface.
+func (p Processes) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER\tPID\t%CPU\t%MEM\tRSS\tSTAT\tTIME\tCOMMAND")
+	for _, proc := range p {
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.1f\t%d\t%s\t%s\t%s\n", proc.User, proc.PID, proc.CPUPercent, proc.MemoryPercent, proc.ResidentSetSize, proc.State, proc.CPUTime, proc.Command)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// SortByCPU returns a copy of the processes sorted by CPU usage,
+// the busiest process first.
+func (p Processes) SortByCPU() Processes {
+	return p.sortBy(func(a, b ProcessInfo) int { return cmp.Compare(b.CPUPercent, a.CPUPercent) })
+}
+
+// SortByMemory returns a copy of the processes sorted by memory usage
+// percentage, the most memory hungry process first.
+func (p Processes) SortByMemory() Processes {
+	return p.sortBy(func(a, b ProcessInfo) int { return cmp.Compare(b.MemoryPercent, a.MemoryPercent) })
+}
+
+// SortByRSS returns a copy of the processes sorted by resident
+// set size, the largest process first.
+func (p Processes) SortByRSS() Processes {
+	return p.sortBy(func(a, b ProcessInfo) int { return cmp.Compare(b.ResidentSetSize, a.ResidentSetSize) })
+}
+
+func (p Processes) sortBy(fn func(a, b ProcessInfo) int) Processes {
+	sorted := slices.Clone(p)
+	slices.SortStableFunc(sorted, fn)
+	return sorted
+}
+
+// Top returns at most n first processes.
+func (p Processes) Top(n int) Processes {
+	if n < 0 || n >= len(p) {
+		return p
+	}
+	return p[:n]
+}
+
+// Filter returns processes for which fn returns true.
+func (p Processes) Filter(fn func(ProcessInfo) bool) Processes {
+	var filtered Processes
+	for _, proc := range p {
+		if fn(proc) {
+			filtered = append(filtered, proc)
+		}
+	}
+	return filtered
+}
+
+// FilterUser returns processes owned by the user.
+func (p Processes) FilterUser(user string) Processes {
+	return p.Filter(func(proc ProcessInfo) bool { return proc.User == user })
+}
+
+// FilterState returns processes in the given state, for example "R"
+// for running or "S" for sleeping. Only the first character of the
+// ps STAT column describes the state, the rest are modifiers.
+func (p Processes) FilterState(state string) Processes {
+	return p.Filter(func(proc ProcessInfo) bool { return strings.HasPrefix(proc.State, state) })
+}
+
+// FilterCommand returns processes with commands matching the regexp.
+func (p Processes) FilterCommand(re *regexp.Regexp) Processes {
+	return p.Filter(func(proc ProcessInfo) bool { return re.MatchString(proc.Command) })
+}
+
+// Zombies returns processes in the zombie (Z) state.
+func (p Processes) Zombies() Processes {
+	return p.FilterState("Z")
+}
+
+// LongRunning returns processes that used at least minCPUTime
+// of CPU time and currently use at least minCPUPercent of CPU.
+// Processes with unparsable CPU time are skipped.
+func (p Processes) LongRunning(minCPUTime time.Duration, minCPUPercent float64) Processes {
+	return p.Filter(func(proc ProcessInfo) bool {
+		d, err := proc.CPUDuration()
+		if err != nil {
+			return false
+		}
+		return d >= minCPUTime && proc.CPUPercent >= minCPUPercent
+	})
+}
+
+// ProcessGroup represents processes sharing the same command basename
+// with their summed resource usage.
+type ProcessGroup struct {
+	Name              string
+	Count             int
+	CPUPercent        float64
+	MemoryPercent     float64
+	VirtualMemorySize uint64
+	ResidentSetSize   uint64
+}
+
+// ProcessGroups represents a list of process groups.
+type ProcessGroups []ProcessGroup
+
+// String implements stringer interface.
+func (g ProcessGroups) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\t%CPU\t%MEM\tVSZ\tRSS\tNAME")
+	for _, group := range g {
+		fmt.Fprintf(w, "%d\t%.1f\t%.1f\t%d\t%d\t%s\n", group.Count, group.CPUPercent, group.MemoryPercent, group.VirtualMemorySize, group.ResidentSetSize, group.Name)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// GroupByCommand groups processes by command basename. Groups are
+// returned in order of their first appearance in the process list.
+func (p Processes) GroupByCommand() ProcessGroups {
+	var groups ProcessGroups
+	index := make(map[string]int)
+	for _, proc := range p {
+		name := proc.Name()
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, ProcessGroup{Name: name})
+		}
+		g := &groups[i]
+		g.Count++
+		g.CPUPercent += proc.CPUPercent
+		g.MemoryPercent += proc.MemoryPercent
+		g.VirtualMemorySize += proc.VirtualMemorySize
+		g.ResidentSetSize += proc.ResidentSetSize
+	}
+	return groups
+}
+
+// SortByCPU returns a copy of the groups sorted by summed CPU usage,
+// the busiest group first.
+func (g ProcessGroups) SortByCPU() ProcessGroups {
+	return g.sortBy(func(a, b ProcessGroup) int { return cmp.Compare(b.CPUPercent, a.CPUPercent) })
+}
+
+// SortByMemory returns a copy of the groups sorted by summed memory
+// usage percentage, the most memory hungry group first.
+func (g ProcessGroups) SortByMemory() ProcessGroups {
+	return g.sortBy(func(a, b ProcessGroup) int { return cmp.Compare(b.MemoryPercent, a.MemoryPercent) })
+}
+
+// SortByRSS returns a copy of the groups sorted by summed resident
+// set size, the largest group first.
+func (g ProcessGroups) SortByRSS() ProcessGroups {
+	return g.sortBy(func(a, b ProcessGroup) int { return cmp.Compare(b.ResidentSetSize, a.ResidentSetSize) })
+}
+
+func (g ProcessGroups) sortBy(fn func(a, b ProcessGroup) int) ProcessGroups {
+	sorted := slices.Clone(g)
+	slices.SortStableFunc(sorted, fn)
+	return sorted
+}
+
+// Top returns at most n first groups.
+func (g ProcessGroups) Top(n int) ProcessGroups {
+	if n < 0 || n >= len(g) {
+		return g
+	}
+	return g[:n]
+}
+
+// Name returns the basename of the process command, for example
+// "python3" for "/usr/bin/python3 app.py" or "sshd" for
+// "sshd: /usr/sbin/sshd -D". Kernel threads keep their bracketed name.
+func (p ProcessInfo) Name() string {
+	if strings.HasPrefix(p.Command, "[") {
+		return p.Command
+	}
+	fields := strings.Fields(p.Command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(path.Base(fields[0]), ":")
+}
+
+// CPUDuration returns the cumulative CPU time of the process. It
+// accepts all ps TIME formats: [DD-]HH:MM:SS and MMM:SS.
+func (p ProcessInfo) CPUDuration() (time.Duration, error) {
+	var days int
+	s := p.CPUTime
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("parsing CPU time %q: %w", p.CPUTime, err)
+		}
+		days, s = n, rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("parsing CPU time %q", p.CPUTime)
+	}
+	var seconds int
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, fmt.Errorf("parsing CPU time %q: %w", p.CPUTime, err)
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(seconds)*time.Second, nil
+}
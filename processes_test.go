@@ -0,0 +1,142 @@
+package mikrus_test
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+)
+
+var testProcesses = mikrus.Processes{
+	{User: "root", PID: 1, CPUPercent: 0.1, MemoryPercent: 1.0, ResidentSetSize: 10748, State: "Ss", CPUTime: "0:04", Command: "/sbin/init"},
+	{User: "www-data", PID: 200, CPUPercent: 35.5, MemoryPercent: 4.0, ResidentSetSize: 41000, State: "R", CPUTime: "1-02:00:00", Command: "php-fpm: pool www"},
+	{User: "www-data", PID: 201, CPUPercent: 2.0, MemoryPercent: 6.5, ResidentSetSize: 66000, State: "S", CPUTime: "0:30", Command: "php-fpm: pool www"},
+	{User: "root", PID: 300, CPUPercent: 0.0, MemoryPercent: 0.0, ResidentSetSize: 0, State: "Z", CPUTime: "0:00", Command: "[sh] <defunct>"},
+	{User: "app", PID: 400, CPUPercent: 12.0, MemoryPercent: 9.0, ResidentSetSize: 92000, State: "Sl", CPUTime: "125:10", Command: "/usr/bin/python3 app.py"},
+}
+
+func pids(ps mikrus.Processes) []uint64 {
+	var list []uint64
+	for _, p := range ps {
+		list = append(list, p.PID)
+	}
+	return list
+}
+
+func TestProcesses_SortsByResourceUsage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		got  mikrus.Processes
+		want []uint64
+	}{
+		{name: "cpu", got: testProcesses.SortByCPU(), want: []uint64{200, 400, 201, 1, 300}},
+		{name: "memory", got: testProcesses.SortByMemory(), want: []uint64{400, 201, 200, 1, 300}},
+		{name: "rss", got: testProcesses.SortByRSS(), want: []uint64{400, 201, 200, 1, 300}},
+	}
+	for _, tc := range tests {
+		if got := pids(tc.got); !slices.Equal(tc.want, got) {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, got))
+		}
+	}
+	if testProcesses[0].PID != 1 {
+		t.Error("sorting modified the original process list")
+	}
+}
+
+func TestProcesses_FiltersByUserStateAndCommand(t *testing.T) {
+	t.Parallel()
+	if got, want := pids(testProcesses.FilterUser("www-data")), []uint64{200, 201}; !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got, want := pids(testProcesses.FilterState("S")), []uint64{1, 201, 400}; !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got, want := pids(testProcesses.FilterCommand(regexp.MustCompile(`^php`))), []uint64{200, 201}; !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if got, want := pids(testProcesses.Zombies()), []uint64{300}; !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestProcesses_GroupsByCommandBasename(t *testing.T) {
+	t.Parallel()
+	want := mikrus.ProcessGroups{
+		{Name: "init", Count: 1, CPUPercent: 0.1, MemoryPercent: 1.0, ResidentSetSize: 10748},
+		{Name: "php-fpm", Count: 2, CPUPercent: 37.5, MemoryPercent: 10.5, ResidentSetSize: 107000},
+		{Name: "[sh] <defunct>", Count: 1},
+		{Name: "python3", Count: 1, CPUPercent: 12.0, MemoryPercent: 9.0, ResidentSetSize: 92000},
+	}
+	got := testProcesses.GroupByCommand()
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestProcessGroups_SortsAndLimitsGroups(t *testing.T) {
+	t.Parallel()
+	groups := testProcesses.GroupByCommand()
+	names := func(g mikrus.ProcessGroups) []string {
+		var names []string
+		for _, group := range g {
+			names = append(names, group.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		name string
+		got  mikrus.ProcessGroups
+		want []string
+	}{
+		{name: "cpu", got: groups.SortByCPU().Top(2), want: []string{"php-fpm", "python3"}},
+		{name: "mem", got: groups.SortByMemory().Top(3), want: []string{"php-fpm", "python3", "init"}},
+		{name: "rss", got: groups.SortByRSS(), want: []string{"php-fpm", "python3", "init", "[sh] <defunct>"}},
+	}
+	for _, tc := range tests {
+		if got := names(tc.got); !slices.Equal(tc.want, got) {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestProcesses_LongRunningReturnsBusyProcesses(t *testing.T) {
+	t.Parallel()
+	got := pids(testProcesses.LongRunning(time.Hour, 10))
+	want := []uint64{200, 400}
+	if !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestProcessInfo_CPUDurationParsesPSTimeFormats(t *testing.T) {
+	t.Parallel()
+	tests := map[string]time.Duration{
+		"0:04":        4 * time.Second,
+		"125:10":      125*time.Minute + 10*time.Second,
+		"00:01:02":    time.Minute + 2*time.Second,
+		"2-03:04:05":  51*time.Hour + 4*time.Minute + 5*time.Second,
+		"12:00:00":    12 * time.Hour,
+		"10-00:00:00": 240 * time.Hour,
+	}
+	for input, want := range tests {
+		got, err := mikrus.ProcessInfo{CPUTime: input}.CPUDuration()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != got {
+			t.Errorf("%s: want %v, got %v", input, want, got)
+		}
+	}
+}
+
+func TestProcessInfo_CPUDurationErrorsForInvalidInput(t *testing.T) {
+	t.Parallel()
+	_, err := mikrus.ProcessInfo{CPUTime: "bogus"}.CPUDuration()
+	if err == nil {
+		t.Fatal("want error for invalid input, got nil")
+	}
+}
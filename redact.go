@@ -0,0 +1,87 @@
+package mikrus
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets removed by a Redactor.
+const Redacted = "REDACTED"
+
+// DefaultRedactPatterns match secrets in responses of the Mikrus API,
+// like database passwords returned by the db verb, in plain text,
+// JSON and form encoded data.
+var DefaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(?:^|[^a-z]|\\[nrt])(?:haslo|hasło|password|passwd|pass|pwd|secret|token)["']?\s*[:=]\s*["']?([^\s"'\\,;&]+)`),
+}
+
+// Redactor removes secrets from text before it is shown or stored,
+// like errors, debug logs and recorded API calls.
+type Redactor struct {
+	// Secrets are literal secrets, like API keys. They are also
+	// redacted when URL encoded.
+	Secrets []string
+
+	// Patterns match secrets in addition to DefaultRedactPatterns.
+	// If a pattern has capture groups, only text matched by the groups
+	// is redacted, otherwise the whole match is.
+	Patterns []*regexp.Regexp
+}
+
+// Redact returns s with secrets replaced by Redacted.
+func (r *Redactor) Redact(s string) string {
+	var secrets []string
+	var patterns []*regexp.Regexp
+	if r != nil {
+		secrets, patterns = r.Secrets, r.Patterns
+	}
+	for _, secret := range secrets {
+		s = redactSecret(s, secret)
+	}
+	for _, p := range DefaultRedactPatterns {
+		s = redactPattern(s, p)
+	}
+	for _, p := range patterns {
+		s = redactPattern(s, p)
+	}
+	return s
+}
+
+func redactSecret(s, secret string) string {
+	if secret == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, secret, Redacted)
+	if escaped := url.QueryEscape(secret); escaped != secret {
+		s = strings.ReplaceAll(s, escaped, Redacted)
+	}
+	return s
+}
+
+func redactPattern(s string, p *regexp.Regexp) string {
+	if p.NumSubexp() == 0 {
+		return p.ReplaceAllLiteralString(s, Redacted)
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range p.FindAllStringSubmatchIndex(s, -1) {
+		for i := 2; i < len(m); i += 2 {
+			start, end := m[i], m[i+1]
+			if start < last || start == end {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Redacted)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// redact returns s with the API key of the client and secrets known
+// to its Redactor removed.
+func (c *Client) redact(s string) string {
+	return c.Redactor.Redact(redactSecret(s, c.apiKey))
+}
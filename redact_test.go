@@ -0,0 +1,80 @@
+package mikrus_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
+)
+
+func TestRedactor_RemovesSecrets(t *testing.T) {
+	t.Parallel()
+	r := &mikrus.Redactor{
+		Secrets:  []string{"k3y/+="},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`ghp_\w+`), regexp.MustCompile(`PGPASSWORD=(\S+)`)},
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{in: "invalid key k3y/+=", want: "invalid key REDACTED"},
+		{in: "key=k3y%2F%2B%3D&srv=j230", want: "key=REDACTED&srv=j230"},
+		{in: "Host: psql01.mikr.us\nLogin: j230\nHaslo: P4ssJ230\nBaza: db_j230\n", want: "Host: psql01.mikr.us\nLogin: j230\nHaslo: REDACTED\nBaza: db_j230\n"},
+		{in: `{"postgres":"Login: j230\nHaslo: P4ssJ230\nBaza: db_j230"}`, want: `{"postgres":"Login: j230\nHaslo: REDACTED\nBaza: db_j230"}`},
+		{in: `{"password": "s3cret", "user": "admin"}`, want: `{"password": "REDACTED", "user": "admin"}`},
+		{in: "mysql --password=s3cret -u admin", want: "mysql --password=REDACTED -u admin"},
+		{in: "clone with ghp_abc123", want: "clone with REDACTED"},
+		{in: "PGPASSWORD=s3cret psql", want: "PGPASSWORD=REDACTED psql"},
+		{in: "uploaded ssh key of admin", want: "uploaded ssh key of admin"},
+	}
+	for _, tc := range tests {
+		if got := r.Redact(tc.in); got != tc.want {
+			t.Errorf("Redact(%q): want %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestRedactor_NilUsesDefaultPatterns(t *testing.T) {
+	t.Parallel()
+	var r *mikrus.Redactor
+	if got, want := r.Redact("Haslo: P4ss"), "Haslo: REDACTED"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestClient_RedactsSecretsFromErrorsAndLogs(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Errors = []mikrustest.ErrorRule{
+		{Verb: "info", Status: http.StatusForbidden, Body: "invalid key testKey for j230"},
+		{Verb: "exec", Status: http.StatusBadGateway, Body: "Login: j230\nHaslo: P4ssJ230\nclone with ghp_abc123"},
+	}
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	var logs bytes.Buffer
+	c := ts.Client("j230")
+	c.Logger = slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c.Redactor = &mikrus.Redactor{Patterns: []*regexp.Regexp{regexp.MustCompile(`ghp_\w+`)}}
+
+	_, infoErr := c.Info()
+	_, execErr := c.Exec("cat /root/.my.cnf")
+	for _, err := range []error{infoErr, execErr} {
+		if err == nil {
+			t.Fatal("want error, got nil")
+		}
+	}
+	got := infoErr.Error() + "\n" + execErr.Error() + "\n" + logs.String()
+	for _, secret := range []string{"testKey", "P4ssJ230", "ghp_abc123"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("want %q redacted, got:\n%s", secret, got)
+		}
+	}
+	if !strings.Contains(infoErr.Error(), "invalid key REDACTED for j230") {
+		t.Errorf("want redacted error message, got %v", infoErr)
+	}
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// funcs are helpers available in report templates.
+var funcs = map[string]any{
+	"bytes":  formatBytes,
+	"uptime": formatUptime,
+	"date":   func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"cell":   func(s string) string { return strings.ReplaceAll(strings.TrimSpace(s), "|", `\|`) },
+	"pct": func(used, total int) string {
+		if total == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f%%", float64(used)/float64(total)*100)
+	},
+}
+
+var (
+	markdownTemplate = texttemplate.Must(texttemplate.New("report.md.tmpl").Funcs(funcs).ParseFS(templates, "templates/report.md.tmpl"))
+	htmlTemplate     = htmltemplate.Must(htmltemplate.New("report.html.tmpl").Funcs(funcs).ParseFS(templates, "templates/report.html.tmpl"))
+)
+
+// WriteMarkdown writes the report in Markdown.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	return markdownTemplate.Execute(w, r)
+}
+
+// WriteHTML writes the report as a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+// WriteJSON writes the report in JSON, in the format read by Load.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// formatBytes formats the size in bytes with a binary unit, like 7.5G.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatUptime formats the uptime in days and hours.
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days == 0 {
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
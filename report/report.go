@@ -0,0 +1,356 @@
+// Package report builds account-wide summary reports of Mikrus servers.
+//
+// A report has a section for every server with its parameters, expiry,
+// resource usage and recent tasks, totals across the fleet, warnings
+// about servers needing attention and changes since a previous report.
+// Reports are rendered in Markdown, HTML or JSON, and saved as JSON,
+// so the next report can be compared with them.
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Lister lists servers of an account. *mikrus.Client implements Lister.
+type Lister interface {
+	Servers() (mikrus.Servers, error)
+}
+
+// Source provides details of a single server. *mikrus.Client
+// implements Source.
+type Source interface {
+	Info() (mikrus.Server, error)
+	Stats() (mikrus.Stats, error)
+	Logs() (mikrus.Logs, error)
+}
+
+// Target is a server with a configured API key, so its details, stats
+// and logs are included in the report.
+type Target struct {
+	ServerID string
+	Source   Source
+}
+
+// Config configures building a report.
+type Config struct {
+	// Accounts list servers of accounts. Servers without a Target
+	// are reported with parameters from the list only.
+	Accounts []Lister
+
+	// Targets are servers with configured API keys.
+	Targets []Target
+
+	// ExpiryDays is the number of days before expiry when servers
+	// get a warning. If zero, DefaultExpiryDays is used.
+	ExpiryDays int
+
+	// DiskThreshold is the disk usage in percent above which servers
+	// get a warning. If zero, DefaultDiskThreshold is used.
+	DiskThreshold float64
+
+	// Logs is the number of recent log entries of every server in
+	// the report. If zero, DefaultLogs is used.
+	Logs int
+}
+
+// Defaults of Config.
+const (
+	DefaultExpiryDays    = 14
+	DefaultDiskThreshold = 90
+	DefaultLogs          = 5
+)
+
+// Report is a summary of all servers.
+type Report struct {
+	Generated time.Time `json:"generated"`
+
+	// Previous is when the report compared with was generated.
+	Previous time.Time `json:"previous,omitzero"`
+
+	Servers  []Server `json:"servers"`
+	Totals   Totals   `json:"totals"`
+	Warnings []Notice `json:"warnings"`
+	Changes  []Notice `json:"changes"`
+}
+
+// Server is the section of a server in the report. RAM and memory
+// are in megabytes, disk parameter in gigabytes and disk usage
+// in bytes.
+type Server struct {
+	ID       string `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Expires  string `json:"expires"`
+	DaysLeft int    `json:"days_left"`
+	RAM      int    `json:"ram"`
+	Disk     int    `json:"disk"`
+	Pro      bool   `json:"pro"`
+
+	// Configured reports whether the server has a Target, so the
+	// fields below are filled.
+	Configured bool `json:"configured"`
+
+	MemoryUsed  int           `json:"memory_used,omitempty"`
+	MemoryTotal int           `json:"memory_total,omitempty"`
+	DiskUsed    uint64        `json:"disk_used,omitempty"`
+	DiskSize    uint64        `json:"disk_size,omitempty"`
+	DiskUsage   float64       `json:"disk_usage,omitempty"`
+	Uptime      time.Duration `json:"uptime,omitempty"`
+	Load15      float64       `json:"load15,omitempty"`
+	Logs        []Log         `json:"logs,omitempty"`
+
+	// Errors are errors of API calls made for the server.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Log is a log entry of a task with its parsed result.
+type Log struct {
+	mikrus.Log
+	Result mikrus.Result `json:"result"`
+}
+
+// Totals sums parameters and usage of all servers. Usage is summed
+// over configured servers only.
+type Totals struct {
+	Servers     int    `json:"servers"`
+	RAM         int    `json:"ram"`
+	Disk        int    `json:"disk"`
+	MemoryUsed  int    `json:"memory_used"`
+	MemoryTotal int    `json:"memory_total"`
+	DiskUsed    uint64 `json:"disk_used"`
+	DiskSize    uint64 `json:"disk_size"`
+}
+
+// Notice is a warning or a change concerning a server.
+type Notice struct {
+	ServerID string `json:"server_id"`
+	Message  string `json:"message"`
+}
+
+// Build queries the API and returns the report generated at now.
+// Errors of API calls are reported in sections of servers.
+func Build(cfg Config, now time.Time) *Report {
+	if cfg.ExpiryDays == 0 {
+		cfg.ExpiryDays = DefaultExpiryDays
+	}
+	if cfg.DiskThreshold == 0 {
+		cfg.DiskThreshold = DefaultDiskThreshold
+	}
+	if cfg.Logs == 0 {
+		cfg.Logs = DefaultLogs
+	}
+	r := &Report{Generated: now.UTC()}
+	byID := map[string]*Server{}
+	server := func(id string) *Server {
+		s, ok := byID[id]
+		if !ok {
+			s = &Server{ID: id}
+			byID[id] = s
+		}
+		return s
+	}
+	var errs []Notice
+	for _, a := range cfg.Accounts {
+		list, err := a.Servers()
+		if err != nil {
+			errs = append(errs, Notice{Message: fmt.Sprintf("listing servers: %v", err)})
+			continue
+		}
+		for _, ss := range list {
+			s := server(ss.ServerID)
+			s.Name, s.Expires = ss.ServerName, ss.Expires
+			s.RAM, _ = strconv.Atoi(ss.ParamRam)
+			s.Disk, _ = strconv.Atoi(ss.ParamDisk)
+		}
+	}
+	for _, t := range cfg.Targets {
+		collect(server(t.ServerID), t.Source, cfg.Logs)
+	}
+	for _, id := range slices.Sorted(maps.Keys(byID)) {
+		r.Servers = append(r.Servers, *byID[id])
+	}
+
+	for i := range r.Servers {
+		s := &r.Servers[i]
+		if expires, err := parseDate(s.Expires); err == nil {
+			s.DaysLeft = int(math.Floor(expires.Sub(r.Generated).Hours() / 24))
+		}
+		r.Totals.add(*s)
+		r.Warnings = append(r.Warnings, warnings(*s, cfg)...)
+	}
+	r.Warnings = append(r.Warnings, errs...)
+	return r
+}
+
+// collect fills the section of the configured server.
+func collect(s *Server, src Source, logs int) {
+	s.Configured = true
+	if info, err := src.Info(); err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("info: %v", err))
+	} else {
+		if info.ServerName != "" {
+			s.Name = info.ServerName
+		}
+		s.Expires = info.Expires
+		s.RAM, _ = strconv.Atoi(info.ParamRam)
+		s.Disk, _ = strconv.Atoi(info.ParamDisk)
+		s.Pro = info.Pro()
+	}
+	if stats, err := src.Stats(); err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("stats: %v", err))
+	} else {
+		s.MemoryUsed, s.MemoryTotal = stats.Memory.Used, stats.Memory.Total
+		s.DiskUsed, _ = stats.DiskSpace.UsedBytes()
+		s.DiskSize, _ = stats.DiskSpace.SizeBytes()
+		s.DiskUsage, _ = stats.DiskSpace.UsagePercent()
+		s.Uptime = stats.Uptime.Uptime
+		s.Load15 = stats.Uptime.CPUload15min
+	}
+	if entries, err := src.Logs(); err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("logs: %v", err))
+	} else {
+		for _, l := range entries[:min(logs, len(entries))] {
+			s.Logs = append(s.Logs, Log{Log: l, Result: l.Result()})
+		}
+	}
+}
+
+func (t *Totals) add(s Server) {
+	t.Servers++
+	t.RAM += s.RAM
+	t.Disk += s.Disk
+	t.MemoryUsed += s.MemoryUsed
+	t.MemoryTotal += s.MemoryTotal
+	t.DiskUsed += s.DiskUsed
+	t.DiskSize += s.DiskSize
+}
+
+// warnings returns warnings about the server: near or past expiry,
+// disk usage above the threshold, failed recent tasks and errors.
+func warnings(s Server, cfg Config) []Notice {
+	var ws []Notice
+	warn := func(format string, args ...any) {
+		ws = append(ws, Notice{ServerID: s.ID, Message: fmt.Sprintf(format, args...)})
+	}
+	if _, err := parseDate(s.Expires); err == nil {
+		switch {
+		case s.DaysLeft < 0:
+			warn("expired on %s", s.Expires)
+		case s.DaysLeft <= cfg.ExpiryDays:
+			warn("expires in %d days, on %s", s.DaysLeft, s.Expires)
+		}
+	}
+	if s.DiskUsage >= cfg.DiskThreshold {
+		warn("disk %.0f%% full", s.DiskUsage)
+	}
+	for _, l := range s.Logs {
+		if l.Result.Failed {
+			warn("task %s %s failed: %s", l.Result.Task, l.ID, l.Result.Message)
+		}
+	}
+	for _, err := range s.Errors {
+		warn("%s", err)
+	}
+	return ws
+}
+
+// Compare records changes since the previous report in r.
+func (r *Report) Compare(prev *Report) {
+	r.Previous = prev.Generated
+	r.Changes = nil
+	before := map[string]Server{}
+	for _, s := range prev.Servers {
+		before[s.ID] = s
+	}
+	change := func(id, format string, args ...any) {
+		r.Changes = append(r.Changes, Notice{ServerID: id, Message: fmt.Sprintf(format, args...)})
+	}
+	for _, s := range r.Servers {
+		old, ok := before[s.ID]
+		delete(before, s.ID)
+		if !ok {
+			change(s.ID, "new server")
+			continue
+		}
+		if old.Expires != s.Expires && s.Expires != "" {
+			if s.Expires > old.Expires {
+				change(s.ID, "renewed until %s", s.Expires)
+			} else {
+				change(s.ID, "expiry changed from %s to %s", old.Expires, s.Expires)
+			}
+		}
+		if old.RAM != s.RAM && s.RAM != 0 {
+			change(s.ID, "RAM changed from %d MB to %d MB", old.RAM, s.RAM)
+		}
+		if old.Disk != s.Disk && s.Disk != 0 {
+			change(s.ID, "disk changed from %d GB to %d GB", old.Disk, s.Disk)
+		}
+		if old.Configured && s.Configured && old.DiskSize > 0 && s.DiskSize > 0 {
+			if d := s.DiskUsage - old.DiskUsage; d >= 5 || d <= -5 {
+				change(s.ID, "disk usage changed from %.0f%% to %.0f%%", old.DiskUsage, s.DiskUsage)
+			}
+		}
+		for _, l := range slices.Backward(s.Logs) {
+			created, err := l.CreatedAt()
+			if err == nil && created.After(prev.Generated) {
+				change(s.ID, "task %s %s: %s", l.Result.Task, l.ID, l.Result)
+			}
+		}
+	}
+	for _, s := range prev.Servers {
+		if _, ok := before[s.ID]; ok {
+			change(s.ID, "server removed")
+		}
+	}
+}
+
+// Load reads the report saved to the file. It returns nil if the file
+// does not exist.
+func Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("decoding %q: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Save writes the report to the file as JSON, creating its directory
+// when needed.
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// parseDate parses dates returned by the Mikrus API in mikrus.Location,
+// like times of log entries compared with Report.Generated.
+func parseDate(s string) (time.Time, error) {
+	return mikrus.Server{Expires: s}.ExpiresAt()
+}
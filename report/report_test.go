@@ -0,0 +1,231 @@
+package report_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrusfake"
+	"github.com/qba73/mikrus/report"
+)
+
+var now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// newConfig returns a config of an account with servers j230, full
+// and expiring soon, and a101, without a profile.
+func newConfig() (report.Config, *mikrusfake.Server) {
+	j230 := mikrusfake.New(mikrusfake.Config{
+		Server: mikrus.Server{ServerID: "j230", ServerName: "web", Expires: "2026-10-08 00:00:00", ParamRam: "1024", ParamDisk: "10", MikrusPro: "tak"},
+		Servers: mikrus.Servers{
+			{ServerID: "j230", ServerName: "web", Expires: "2026-10-08 00:00:00", ParamRam: "1024", ParamDisk: "10"},
+			{ServerID: "a101", Expires: "2027-01-01 00:00:00", ParamRam: "2048", ParamDisk: "20"},
+		},
+		Stats: mikrus.Stats{
+			Memory:    mikrus.Memory{Total: 1024, Used: 512},
+			DiskSpace: mikrus.DiskSpace{Size: "10G", Used: "9.5G", Usage: "95%"},
+			Uptime:    mikrus.Uptime{Uptime: 50 * time.Hour, CPUload15min: 0.25},
+		},
+		Logs: mikrus.Logs{
+			{ID: "3752", ServerID: "j230", Task: "kluczssh", WhenCreated: "2026-09-30 10:05:34", WhenDone: "2026-09-30 10:06:01", Output: "Wrzuciłem klucz SSH\n"},
+			{ID: "3751", ServerID: "j230", Task: "restart", WhenCreated: "2026-09-29 09:57:54", WhenDone: "2026-09-29 09:58:07", Output: "Error: timeout\n"},
+		},
+	})
+	return report.Config{
+		Accounts: []report.Lister{j230},
+		Targets:  []report.Target{{ServerID: "j230", Source: j230}},
+	}, j230
+}
+
+func TestBuild_ReportsServersTotalsAndWarnings(t *testing.T) {
+	t.Parallel()
+	cfg, _ := newConfig()
+	r := report.Build(cfg, now)
+
+	var ids []string
+	for _, s := range r.Servers {
+		ids = append(ids, s.ID)
+	}
+	if want := []string{"a101", "j230"}; !cmp.Equal(want, ids) {
+		t.Error(cmp.Diff(want, ids))
+	}
+	a101, j230 := r.Servers[0], r.Servers[1]
+	if a101.Configured || a101.RAM != 2048 || a101.DaysLeft != 91 {
+		t.Errorf("want a101 from the server list, got %+v", a101)
+	}
+	if !j230.Configured || !j230.Pro || j230.DaysLeft != 6 || j230.DiskUsage != 95 || len(j230.Logs) != 2 {
+		t.Errorf("want details of j230, got %+v", j230)
+	}
+	wantTotals := report.Totals{Servers: 2, RAM: 3072, Disk: 30, MemoryUsed: 512, MemoryTotal: 1024, DiskUsed: 10200547328, DiskSize: 10737418240}
+	if !cmp.Equal(wantTotals, r.Totals) {
+		t.Error(cmp.Diff(wantTotals, r.Totals))
+	}
+	wantWarnings := []report.Notice{
+		{ServerID: "j230", Message: "expires in 6 days, on 2026-10-08 00:00:00"},
+		{ServerID: "j230", Message: "disk 95% full"},
+		{ServerID: "j230", Message: "task restart 3751 failed: Error: timeout"},
+	}
+	if !cmp.Equal(wantWarnings, r.Warnings) {
+		t.Error(cmp.Diff(wantWarnings, r.Warnings))
+	}
+}
+
+func TestBuild_WarnsAboutServersExpiredHoursAgo(t *testing.T) {
+	t.Parallel()
+	expires := now.Add(-time.Hour).In(mikrus.Location).Format("2006-01-02 15:04:05")
+	fake := mikrusfake.New(mikrusfake.Config{Servers: mikrus.Servers{{ServerID: "j230", Expires: expires}}})
+	r := report.Build(report.Config{Accounts: []report.Lister{fake}}, now)
+	if got := r.Servers[0].DaysLeft; got != -1 {
+		t.Errorf("want -1 days left, got %d", got)
+	}
+	want := []report.Notice{{ServerID: "j230", Message: "expired on " + expires}}
+	if !cmp.Equal(want, r.Warnings) {
+		t.Error(cmp.Diff(want, r.Warnings))
+	}
+}
+
+func TestBuild_ReportsErrorsOfAPICalls(t *testing.T) {
+	t.Parallel()
+	cfg, fake := newConfig()
+	fake.Fail("Stats", errors.New("boom"))
+	r := report.Build(cfg, now)
+	j230 := r.Servers[1]
+	if want := []string{"stats: boom"}; !cmp.Equal(want, j230.Errors) {
+		t.Error(cmp.Diff(want, j230.Errors))
+	}
+	if got := r.Warnings[len(r.Warnings)-1]; got.Message != "stats: boom" {
+		t.Errorf("want warning about the error, got %+v", got)
+	}
+}
+
+func TestReport_CompareListsChangesSincePreviousReport(t *testing.T) {
+	t.Parallel()
+	cfg, fake := newConfig()
+	prev := report.Build(cfg, now.Add(-30*24*time.Hour))
+	prev.Servers = append(prev.Servers, report.Server{ID: "z999"})
+	prev.Servers[1].Expires = "2026-09-08 00:00:00"
+	prev.Servers[1].RAM = 768
+	prev.Servers[1].DiskUsage = 60
+
+	if _, err := fake.Boost(); err != nil {
+		t.Fatal(err)
+	}
+	r := report.Build(cfg, time.Now().Add(time.Minute))
+	r.Compare(prev)
+
+	var got []string
+	for _, c := range r.Changes {
+		got = append(got, c.ServerID+": "+c.Message)
+	}
+	want := []string{
+		"j230: renewed until 2026-10-08 00:00:00",
+		"j230: RAM changed from 768 MB to 1024 MB",
+		"j230: disk usage changed from 60% to 95%",
+		"j230: task restart 3751: failed: Error: timeout",
+		"j230: task sshkey 3752: succeeded: Uploaded SSH key",
+		"j230: task boost 3753: done: Amfetamina aktywna przez 30 minut",
+		"z999: server removed",
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestReport_CompareListsTasksCreatedAfterPreviousReportInAPITimeZone(t *testing.T) {
+	t.Parallel()
+	fake := mikrusfake.New(mikrusfake.Config{
+		Server: mikrus.Server{ServerID: "j230"},
+		Logs: mikrus.Logs{
+			// 12:30 UTC, after the previous report.
+			{ID: "2", ServerID: "j230", Task: "restart", WhenCreated: "2026-10-01 14:30:00", WhenDone: "2026-10-01 14:30:10", Output: "OK"},
+			// 11:30 UTC, before the previous report.
+			{ID: "1", ServerID: "j230", Task: "restart", WhenCreated: "2026-10-01 13:30:00", WhenDone: "2026-10-01 13:30:10", Output: "OK"},
+		},
+	})
+	cfg := report.Config{Targets: []report.Target{{ServerID: "j230", Source: fake}}}
+	prev := report.Build(cfg, now)
+	r := report.Build(cfg, now.Add(time.Hour))
+	r.Compare(prev)
+	want := []report.Notice{{ServerID: "j230", Message: "task restart 2: succeeded: Server restarted"}}
+	if !cmp.Equal(want, r.Changes) {
+		t.Error(cmp.Diff(want, r.Changes))
+	}
+}
+
+func TestReport_SavesAndLoadsReports(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "reports", "report.json")
+	prev, err := report.Load(path)
+	if err != nil || prev != nil {
+		t.Fatalf("want no report before saving, got %v, %v", prev, err)
+	}
+	cfg, _ := newConfig()
+	r := report.Build(cfg, now)
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := report.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(r, got) {
+		t.Error(cmp.Diff(r, got))
+	}
+}
+
+func TestReport_RendersFormats(t *testing.T) {
+	t.Parallel()
+	cfg, _ := newConfig()
+	r := report.Build(cfg, now)
+	r.Compare(&report.Report{Generated: now.Add(-time.Hour), Servers: r.Servers})
+	tests := []struct {
+		name  string
+		write func(*bytes.Buffer) error
+		want  []string
+	}{
+		{
+			name:  "markdown",
+			write: func(b *bytes.Buffer) error { return r.WriteMarkdown(b) },
+			want: []string{
+				"| 2 | 3072 MB | 30 GB | 512 / 1024 MB | 9.5G / 10.0G |",
+				"- **j230**: disk 95% full\n",
+				"## Changes since 2026-10-01 11:00 UTC\n\nNone.\n",
+				"### j230 (web)\n",
+				"| 3751 | restart | 2026-09-29 09:57:54 | failed: Error: timeout |\n",
+				"- Uptime: 2d 2h, load 0.25\n",
+				"### a101\n",
+				"No profile configured",
+			},
+		},
+		{
+			name:  "html",
+			write: func(b *bytes.Buffer) error { return r.WriteHTML(b) },
+			want: []string{
+				"<title>Mikrus report 2026-10-01 12:00 UTC</title>",
+				`<li class="warning"><strong>j230</strong>: disk 95% full</li>`,
+				"<h3>j230 (web)</h3>",
+				"<td>failed: Error: timeout</td>",
+			},
+		},
+		{
+			name:  "json",
+			write: func(b *bytes.Buffer) error { return r.WriteJSON(b) },
+			want:  []string{`"generated": "2026-10-01T12:00:00Z"`, `"disk_usage": 95`},
+		},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		if err := tc.write(&buf); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%s: want output to contain %q, got:\n%s", tc.name, want, buf.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package sshconfig derives SSH connection details of Mikrus servers
+// from their IDs and renders them as ssh_config blocks.
+//
+// The SSH port of a server is 10000 plus the number in its server ID,
+// like 10230 for server j230. Host names depend on the machine the
+// server runs on and are not reported by the API, so they are
+// configured per server ID or server ID prefix.
+package sshconfig
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Port returns the SSH port of the server.
+func Port(serverID string) (int, error) {
+	digits := strings.TrimLeft(serverID, "abcdefghijklmnopqrstuvwxyz")
+	n, err := strconv.Atoi(digits)
+	if err != nil || digits == "" || digits == serverID || n < 0 || n > 55535 {
+		return 0, fmt.Errorf("cannot derive SSH port from server ID %q", serverID)
+	}
+	return 10000 + n, nil
+}
+
+// Host represents SSH connection details of a server.
+type Host struct {
+	Alias        string
+	ServerID     string
+	ServerName   string
+	HostName     string
+	Port         int
+	User         string
+	IdentityFile string
+}
+
+// Args returns arguments of the ssh command connecting to the host,
+// followed by extra arguments, like a remote command.
+func (h Host) Args(extra ...string) []string {
+	args := []string{"-p", strconv.Itoa(h.Port)}
+	if h.IdentityFile != "" {
+		args = append(args, "-i", h.IdentityFile)
+	}
+	target := h.HostName
+	if h.User != "" {
+		target = h.User + "@" + target
+	}
+	return append(append(args, target), extra...)
+}
+
+// Resolver derives connection details from server IDs.
+type Resolver struct {
+	// Hosts maps server IDs, or server ID prefixes like "j",
+	// to host names. Server IDs take precedence over the longest
+	// matching prefix.
+	Hosts map[string]string
+
+	// User is the SSH user, "root" if empty.
+	User string
+
+	// IdentityFile is an optional private key file.
+	IdentityFile string
+
+	// AliasPrefix is prepended to server IDs to form host aliases.
+	AliasPrefix string
+}
+
+// Resolve returns connection details of the server.
+func (r Resolver) Resolve(serverID string) (Host, error) {
+	port, err := Port(serverID)
+	if err != nil {
+		return Host{}, err
+	}
+	hostName, ok := r.Hosts[serverID]
+	if !ok {
+		var prefix string
+		for p, h := range r.Hosts {
+			if strings.HasPrefix(serverID, p) && len(p) > len(prefix) {
+				prefix, hostName = p, h
+			}
+		}
+		if prefix == "" {
+			return Host{}, fmt.Errorf("unknown SSH host of server %q, set it in ssh.hosts config or with --host", serverID)
+		}
+	}
+	user := r.User
+	if user == "" {
+		user = "root"
+	}
+	return Host{
+		Alias:        r.AliasPrefix + serverID,
+		ServerID:     serverID,
+		HostName:     hostName,
+		Port:         port,
+		User:         user,
+		IdentityFile: r.IdentityFile,
+	}, nil
+}
+
+var configTemplate = template.Must(template.New("ssh_config").Parse(`{{ range . -}}
+# Mikrus server {{ .ServerID }}{{ with .ServerName }} ({{ . }}){{ end }}
+Host {{ .Alias }}
+    HostName {{ .HostName }}
+    Port {{ .Port }}
+    User {{ .User }}
+{{- with .IdentityFile }}
+    IdentityFile {{ . }}
+{{- end }}
+
+{{ end }}`))
+
+// WriteConfig writes ssh_config blocks of the hosts sorted by alias.
+func WriteConfig(w io.Writer, hosts []Host) error {
+	sorted := append([]Host(nil), hosts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Alias < sorted[j].Alias })
+	return configTemplate.Execute(w, sorted)
+}
@@ -0,0 +1,117 @@
+package sshconfig_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qba73/mikrus/sshconfig"
+)
+
+func TestPort_DerivesPortFromServerID(t *testing.T) {
+	t.Parallel()
+	tests := map[string]int{"j230": 10230, "a101": 10101, "frog05": 10005, "x1": 10001}
+	for id, want := range tests {
+		got, err := sshconfig.Port(id)
+		if err != nil {
+			t.Errorf("%s: %v", id, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s: want port %d, got %d", id, want, got)
+		}
+	}
+}
+
+func TestPort_ErrorsForInvalidServerID(t *testing.T) {
+	t.Parallel()
+	for _, id := range []string{"", "j", "230", "j23x", "j-1", "j99999"} {
+		if _, err := sshconfig.Port(id); err == nil {
+			t.Errorf("%q: want error, got nil", id)
+		}
+	}
+}
+
+var resolver = sshconfig.Resolver{
+	Hosts: map[string]string{
+		"j":    "srv07.mikr.us",
+		"j2":   "srv08.mikr.us",
+		"j230": "srv09.mikr.us",
+		"a":    "srv01.mikr.us",
+	},
+	AliasPrefix: "mikrus-",
+}
+
+func TestResolve_PrefersServerIDOverLongestPrefix(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{"j230": "srv09.mikr.us", "j231": "srv08.mikr.us", "j100": "srv07.mikr.us", "a101": "srv01.mikr.us"}
+	for id, want := range tests {
+		h, err := resolver.Resolve(id)
+		if err != nil {
+			t.Errorf("%s: %v", id, err)
+			continue
+		}
+		if h.HostName != want {
+			t.Errorf("%s: want host %q, got %q", id, want, h.HostName)
+		}
+	}
+}
+
+func TestResolve_ErrorsForUnknownHost(t *testing.T) {
+	t.Parallel()
+	if _, err := resolver.Resolve("b101"); err == nil {
+		t.Fatal("want error for server without configured host, got nil")
+	}
+}
+
+func TestHost_ArgsConnectAsUserOnDerivedPort(t *testing.T) {
+	t.Parallel()
+	r := resolver
+	r.IdentityFile = "~/.ssh/mikrus"
+	h, err := r.Resolve("j230")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := h.Args("uptime")
+	want := []string{"-p", "10230", "-i", "~/.ssh/mikrus", "root@srv09.mikr.us", "uptime"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestWriteConfig_RendersBlocksSortedByAlias(t *testing.T) {
+	t.Parallel()
+	j230, err := resolver.Resolve("j230")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j230.ServerName = "web"
+	a101, err := resolver.Resolve("a101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a101.User = "deploy"
+	a101.IdentityFile = "~/.ssh/id_ed25519"
+
+	var b strings.Builder
+	if err := sshconfig.WriteConfig(&b, []sshconfig.Host{j230, a101}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Mikrus server a101
+Host mikrus-a101
+    HostName srv01.mikr.us
+    Port 10101
+    User deploy
+    IdentityFile ~/.ssh/id_ed25519
+
+# Mikrus server j230 (web)
+Host mikrus-j230
+    HostName srv09.mikr.us
+    Port 10230
+    User root
+
+`
+	if got := b.String(); got != want {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,95 @@
+package mikrus
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SSHKeyTypes lists supported types of OpenSSH public keys.
+var SSHKeyTypes = []string{
+	"ssh-ed25519",
+	"ssh-rsa",
+	"ecdsa-sha2-nistp256",
+	"ecdsa-sha2-nistp384",
+	"ecdsa-sha2-nistp521",
+	"sk-ssh-ed25519@openssh.com",
+	"sk-ecdsa-sha2-nistp256@openssh.com",
+}
+
+// SSHPublicKey represents an OpenSSH public key, like a line
+// of ~/.ssh/id_ed25519.pub.
+type SSHPublicKey struct {
+	Type    string
+	Key     []byte
+	Comment string
+}
+
+// String returns the key in the OpenSSH format.
+func (k SSHPublicKey) String() string {
+	s := k.Type + " " + base64.StdEncoding.EncodeToString(k.Key)
+	if k.Comment != "" {
+		s += " " + k.Comment
+	}
+	return s
+}
+
+// ParseSSHPublicKey parses a public key in the OpenSSH format:
+// the key type, the base64 encoded key and an optional comment.
+// The key type must be one of SSHKeyTypes and match the type
+// encoded in the key.
+func ParseSSHPublicKey(s string) (SSHPublicKey, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return SSHPublicKey{}, errors.New("empty SSH public key")
+	}
+	if strings.HasPrefix(s, "-----BEGIN") {
+		return SSHPublicKey{}, errors.New("got a private key or PEM file, want an OpenSSH public key")
+	}
+	if strings.ContainsAny(s, "\r\n") {
+		return SSHPublicKey{}, errors.New("SSH public key must be a single line")
+	}
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return SSHPublicKey{}, errors.New("invalid SSH public key, want: type base64-key [comment]")
+	}
+	keyType := fields[0]
+	if !slices.Contains(SSHKeyTypes, keyType) {
+		return SSHPublicKey{}, fmt.Errorf("unsupported SSH key type %q", keyType)
+	}
+	key, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return SSHPublicKey{}, fmt.Errorf("invalid SSH public key data: %w", err)
+	}
+	encodedType, rest, err := readSSHString(key)
+	if err != nil {
+		return SSHPublicKey{}, fmt.Errorf("invalid SSH public key data: %w", err)
+	}
+	if len(rest) == 0 {
+		return SSHPublicKey{}, errors.New("invalid SSH public key data: missing key")
+	}
+	if encodedType != keyType {
+		return SSHPublicKey{}, fmt.Errorf("SSH key type %q does not match key data of type %q", keyType, encodedType)
+	}
+	return SSHPublicKey{
+		Type:    keyType,
+		Key:     key,
+		Comment: strings.Join(fields[2:], " "),
+	}, nil
+}
+
+// readSSHString reads the first length-prefixed string of the key
+// in the SSH wire format and returns it with the remaining data.
+func readSSHString(b []byte) (string, []byte, error) {
+	if len(b) < 4 {
+		return "", nil, errors.New("too short")
+	}
+	n := binary.BigEndian.Uint32(b)
+	if uint64(n) > uint64(len(b)-4) {
+		return "", nil, errors.New("truncated")
+	}
+	return string(b[4 : 4+n]), b[4+n:], nil
+}
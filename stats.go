@@ -3,17 +3,20 @@ package mikrus
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type Stats struct {
-	Memory    Memory        `json:"memory"`
-	DiskSpace DiskSpace     `json:"disk_space"`
-	Uptime    Uptime        `json:"uptime"`
-	Processes []ProcessInfo `json:"processes"`
+	Memory    Memory    `json:"memory"`
+	DiskSpace DiskSpace `json:"disk_space"`
+	Uptime    Uptime    `json:"uptime"`
+	Processes Processes `json:"processes"`
 }
 
 type Memory struct {
@@ -71,9 +74,54 @@ type DiskSpace struct {
 	MountedOn  string `json:"mounted_on"`
 }
 
+// SizeBytes returns the filesystem size in bytes.
+func (d DiskSpace) SizeBytes() (uint64, error) {
+	return parseSize(d.Size)
+}
+
+// UsedBytes returns the used space in bytes.
+func (d DiskSpace) UsedBytes() (uint64, error) {
+	return parseSize(d.Used)
+}
+
+// AvailableBytes returns the available space in bytes.
+func (d DiskSpace) AvailableBytes() (uint64, error) {
+	return parseSize(d.Available)
+}
+
+// UsagePercent returns the used space as percentage of
+// the filesystem size, as reported in the df Use% column.
+func (d DiskSpace) UsagePercent() (float64, error) {
+	usage, err := strconv.ParseFloat(strings.TrimSuffix(d.Usage, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing disk usage %q: %w", d.Usage, err)
+	}
+	return usage, nil
+}
+
+// parseSize parses human readable sizes printed by `df -h`,
+// like 9.8G or 512M, into bytes.
+func parseSize(s string) (uint64, error) {
+	const units = "KMGTPE"
+	multiplier := 1.0
+	num := s
+	if s != "" {
+		if i := strings.IndexByte(units, s[len(s)-1]); i >= 0 {
+			num = s[:len(s)-1]
+			multiplier = math.Pow(1024, float64(i+1))
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("parsing size %q", s)
+	}
+	return uint64(n * multiplier), nil
+}
+
 type ProcessInfo struct {
 	User              string
 	PID               uint64
+	PPID              uint64
 	CPUPercent        float64
 	MemoryPercent     float64
 	VirtualMemorySize uint64
@@ -85,69 +133,369 @@ type ProcessInfo struct {
 	Command           string
 }
 
-// Format:                      USER    PID     %CPU       %MEM        VSZ    RSS   TTY   STAT   START     TIME     COMMAND
-var psRE = regexp.MustCompile(`^(\w+) +(\d+) +(\d+\.\d+) +(\d+\.\d+) +(\d+) +(\d+) +(.) +(\w+) +([\d:]+) +([\d:]+) +(.+)$`)
+// psField identifies the ProcessInfo field a ps column is parsed into.
+type psField int
+
+const (
+	psUnknown psField = iota
+	psUser
+	psPID
+	psPPID
+	psCPUPercent
+	psMemPercent
+	psVSZ
+	psRSS
+	psTTY
+	psState
+	psStart
+	psTime
+	psCommand
+)
+
+// psColumns maps ps header names to ProcessInfo fields. It covers
+// the headers printed by `ps aux`, `ps -ef` and the most common
+// `ps -o` format specifiers.
+var psColumns = map[string]psField{
+	"USER":    psUser,
+	"UID":     psUser,
+	"RUSER":   psUser,
+	"EUSER":   psUser,
+	"PID":     psPID,
+	"PPID":    psPPID,
+	"%CPU":    psCPUPercent,
+	"C":       psCPUPercent,
+	"%MEM":    psMemPercent,
+	"VSZ":     psVSZ,
+	"RSS":     psRSS,
+	"RSZ":     psRSS,
+	"TTY":     psTTY,
+	"TT":      psTTY,
+	"STAT":    psState,
+	"S":       psState,
+	"START":   psStart,
+	"STIME":   psStart,
+	"STARTED": psStart,
+	"TIME":    psTime,
+	"COMMAND": psCommand,
+	"CMD":     psCommand,
+	"ARGS":    psCommand,
+}
+
+// psColumnSpans lists columns whose values span a varying number
+// of whitespace separated tokens. Columns not listed here take
+// exactly one token.
+var psColumnSpans = map[string]func([]string) int{
+	"STARTED": psStartedSpan,
+}
+
+// psStartedSpan returns the number of tokens taken by the STARTED
+// value at the beginning of tokens. Both `ps -o lstart` and
+// `ps -o start` print the STARTED header: the former prints values
+// like "Mon Jun  5 09:00:00 2024", the latter "09:00:00" for
+// processes started within a day and "Jun 05" for older ones.
+func psStartedSpan(tokens []string) int {
+	switch {
+	case len(tokens) >= 5 && isWeekday(tokens[0]) && isMonth(tokens[1]):
+		return 5
+	case len(tokens) >= 2 && isMonth(tokens[0]) && isNumber(tokens[1]):
+		return 2
+	}
+	return 1
+}
 
+func isWeekday(s string) bool {
+	_, err := time.Parse("Mon", s)
+	return err == nil
+}
+
+func isMonth(s string) bool {
+	_, err := time.Parse("Jan", s)
+	return err == nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+var psHeaderRE = regexp.MustCompile(`^[%A-Z][%A-Z0-9_]*$`)
+
+// psColumn represents a single column located in the ps header row.
+type psColumn struct {
+	name  string
+	field psField
+	span  func([]string) int
+}
+
+// spanFrom returns the number of tokens taken by the column value
+// at the beginning of tokens, or 0 if tokens is empty.
+func (c psColumn) spanFrom(tokens []string) int {
+	if len(tokens) == 0 {
+		return 0
+	}
+	if c.span == nil {
+		return 1
+	}
+	return c.span(tokens)
+}
+
+// spanTo returns the number of tokens taken by the column value
+// at the end of tokens, or 0 if tokens is empty. The longest value
+// that ends exactly at the end of tokens wins.
+func (c psColumn) spanTo(tokens []string) int {
+	n := 0
+	for w := 1; w <= len(tokens); w++ {
+		if c.spanFrom(tokens[len(tokens)-w:]) == w {
+			n = w
+		}
+	}
+	return n
+}
+
+// ParsePS parses the output of the ps command. Columns are located
+// using the header row, so the output of `ps aux`, `ps auxf`, `ps -ef`
+// and custom `ps -o` formats is supported. Any lines preceding the
+// header are ignored.
+//
+// Forest markers (`\_`) printed by `ps f` are stripped from the command
+// and used to fill in the PPID of each process.
 func ParsePS(s string) ([]ProcessInfo, error) {
 	lines := strings.Split(s, "\n")
-	if len(lines) < 2 {
+	header := -1
+	var columns []psColumn
+	for i, line := range lines {
+		if cols, ok := parsePSHeader(line); ok {
+			header, columns = i, cols
+			break
+		}
+	}
+	if header < 0 {
 		return nil, fmt.Errorf("invalid input line: %s", s)
 	}
-	list := make([]ProcessInfo, 0, len(lines)-1)
-	const (
-		USER = iota + 1
-		PID
-		CPUPERCENT
-		MEMPERCENT
-		VSZ
-		RSS
-		TTY
-		STAT
-		START
-		TIME
-		COMMAND
-	)
-	for _, line := range lines[1 : len(lines)-1] {
-		matches := psRE.FindStringSubmatch(line)
-		if len(matches) != 12 {
-			return nil, fmt.Errorf("parsing %q", line)
+	hasPPID := slices.ContainsFunc(columns, func(c psColumn) bool { return c.field == psPPID })
+	list := make([]ProcessInfo, 0, len(lines)-header-1)
+	// parents holds the PID of the last process seen on each level
+	// of the process forest.
+	var parents []uint64
+	for _, line := range lines[header+1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
-		pid, err := strconv.ParseUint(matches[PID], 10, 64)
+		p, depth, err := parsePSLine(line, columns)
 		if err != nil {
 			return nil, err
 		}
-		cpuPercent, err := strconv.ParseFloat(matches[CPUPERCENT], 64)
-		if err != nil {
-			return nil, err
+		if !hasPPID && depth >= 0 {
+			// Entries nested deeper than their predecessors come
+			// from truncated output; their parent is unknown.
+			for len(parents) < depth {
+				parents = append(parents, 0)
+			}
+			if depth > 0 {
+				p.PPID = parents[depth-1]
+			}
+			parents = append(parents[:depth], p.PID)
 		}
-		memPercent, err := strconv.ParseFloat(matches[MEMPERCENT], 64)
-		if err != nil {
-			return nil, err
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// parsePSHeader reports whether the line is a ps header row and
+// returns columns it describes.
+func parsePSHeader(line string) ([]psColumn, bool) {
+	names := strings.Fields(line)
+	if len(names) == 0 {
+		return nil, false
+	}
+	columns := make([]psColumn, 0, len(names))
+	known := false
+	for _, name := range names {
+		if !psHeaderRE.MatchString(name) {
+			return nil, false
 		}
-		vsz, err := strconv.ParseUint(matches[VSZ], 10, 64)
-		if err != nil {
-			return nil, err
+		field := psColumns[name]
+		if field != psUnknown {
+			known = true
 		}
-		rss, err := strconv.ParseUint(matches[RSS], 10, 64)
-		if err != nil {
-			return nil, err
+		columns = append(columns, psColumn{name: name, field: field, span: psColumnSpans[name]})
+	}
+	if !known {
+		return nil, false
+	}
+	return columns, true
+}
+
+// parsePSLine parses a single ps row. It returns the process depth
+// in the process forest, or -1 if the row has no forest information.
+//
+// Columns preceding the command are matched from the start of the
+// row and columns following it from the end, so that the command
+// can take any number of tokens.
+func parsePSLine(line string, columns []psColumn) (ProcessInfo, int, error) {
+	fields := fieldsIndex(line)
+	tokens := make([]string, len(fields))
+	for i, f := range fields {
+		tokens[i] = line[f[0]:f[1]]
+	}
+	command := slices.IndexFunc(columns, func(c psColumn) bool { return c.field == psCommand })
+	before := len(columns)
+	if command >= 0 {
+		before = command
+	}
+	widths := make([]int, len(columns))
+	start, end := 0, len(tokens)
+	for i := range before {
+		widths[i] = columns[i].spanFrom(tokens[start:])
+		if widths[i] == 0 {
+			return ProcessInfo{}, -1, fmt.Errorf("parsing %q", line)
 		}
+		start += widths[i]
+	}
+	for i := len(columns) - 1; i > before; i-- {
+		widths[i] = columns[i].spanTo(tokens[start:end])
+		if widths[i] == 0 {
+			return ProcessInfo{}, -1, fmt.Errorf("parsing %q", line)
+		}
+		end -= widths[i]
+	}
+	if command >= 0 {
+		widths[command] = end - start
+	}
+	if (command >= 0 && widths[command] == 0) || (command < 0 && start != end) {
+		return ProcessInfo{}, -1, fmt.Errorf("parsing %q", line)
+	}
 
-		list = append(list, ProcessInfo{
-			User:              matches[USER],
-			PID:               pid,
-			CPUPercent:        cpuPercent,
-			MemoryPercent:     memPercent,
-			VirtualMemorySize: vsz,
-			ResidentSetSize:   rss,
-			TTY:               matches[TTY],
-			State:             matches[STAT],
-			Start:             matches[START],
-			CPUTime:           matches[TIME],
-			Command:           matches[COMMAND],
-		})
+	var p ProcessInfo
+	depth := -1
+	pos := 0
+	for i, c := range columns {
+		first, last := fields[pos], fields[pos+widths[i]-1]
+		value := line[first[0]:last[1]]
+		if i == command {
+			value, depth = parseForest(line[:first[0]], value, pos)
+		}
+		pos += widths[i]
+		if err := p.set(c.field, value); err != nil {
+			return ProcessInfo{}, -1, fmt.Errorf("parsing %s column in %q: %w", c.name, line, err)
+		}
 	}
-	return list, nil
+	return p, depth, nil
+}
+
+// parseForest strips the `ps f` forest marker from the command and
+// returns its depth in the process forest. Each forest level is
+// printed as four characters, the last one being " \_ ".
+func parseForest(prefix, command string, pos int) (string, int) {
+	if pos == 0 {
+		return command, -1
+	}
+	marker, rest, ok := strings.Cut(command, `\_ `)
+	if !ok || strings.Trim(marker, " |") != "" {
+		return command, 0
+	}
+	// The indentation starts right after the previous column
+	// and includes a single separating space.
+	indent := len(prefix) - len(strings.TrimRight(prefix, " ")) + len(marker)
+	return strings.TrimLeft(rest, " "), indent/4 + 1
+}
+
+// fieldsIndex works like strings.Fields, but returns start and end
+// offsets of the fields in s.
+func fieldsIndex(s string) [][2]int {
+	var fields [][2]int
+	start := -1
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, [2]int{start, i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, [2]int{start, len(s)})
+	}
+	return fields
+}
+
+// set parses the value and stores it in the ProcessInfo field.
+func (p *ProcessInfo) set(field psField, value string) error {
+	var err error
+	switch field {
+	case psUser:
+		p.User = value
+	case psPID:
+		p.PID, err = strconv.ParseUint(value, 10, 64)
+	case psPPID:
+		p.PPID, err = strconv.ParseUint(value, 10, 64)
+	case psCPUPercent:
+		p.CPUPercent, err = strconv.ParseFloat(value, 64)
+	case psMemPercent:
+		p.MemoryPercent, err = strconv.ParseFloat(value, 64)
+	case psVSZ:
+		p.VirtualMemorySize, err = strconv.ParseUint(value, 10, 64)
+	case psRSS:
+		p.ResidentSetSize, err = strconv.ParseUint(value, 10, 64)
+	case psTTY:
+		p.TTY = value
+	case psState:
+		p.State = value
+	case psStart:
+		p.Start = value
+	case psTime:
+		p.CPUTime = value
+	case psCommand:
+		p.Command = value
+	}
+	return err
+}
+
+// ProcessNode represents a process and its child processes.
+type ProcessNode struct {
+	ProcessInfo
+	Children []*ProcessNode
+}
+
+// ProcessTree is a forest of processes. Its roots are processes
+// whose parent is not present in the process list.
+type ProcessTree []*ProcessNode
+
+// NewProcessTree builds a process tree using the PPID of each process.
+// The order of processes is preserved on every level of the tree.
+func NewProcessTree(processes []ProcessInfo) ProcessTree {
+	nodes := make(map[uint64]*ProcessNode, len(processes))
+	for _, p := range processes {
+		nodes[p.PID] = &ProcessNode{ProcessInfo: p}
+	}
+	var tree ProcessTree
+	for _, p := range processes {
+		node := nodes[p.PID]
+		parent, ok := nodes[p.PPID]
+		if !ok || p.PPID == p.PID {
+			tree = append(tree, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return tree
+}
+
+// Walk calls fn for every process in the tree, parents before
+// their children, along with the process depth in the tree.
+func (t ProcessTree) Walk(fn func(p *ProcessNode, depth int)) {
+	var walk func(nodes []*ProcessNode, depth int)
+	walk = func(nodes []*ProcessNode, depth int) {
+		for _, n := range nodes {
+			fn(n, depth)
+			walk(n.Children, depth+1)
+		}
+	}
+	walk(t, 0)
 }
 
 func ParseDiskSpace(s string) (DiskSpace, error) {
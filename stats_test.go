@@ -1,6 +1,8 @@
 package mikrus_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"slices"
 	"testing"
 	"time"
@@ -64,6 +66,47 @@ func TestParseDiskSpace_ParsesCommandOutputOnValidInput(t *testing.T) {
 	}
 }
 
+func TestDiskSpace_ConvertsSizesToBytes(t *testing.T) {
+	t.Parallel()
+	d := mikrus.DiskSpace{Size: "9.8G", Used: "512M", Available: "0", Usage: "29%"}
+	size, err := d.SizeBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(10522669875); want != size {
+		t.Errorf("want size %d, got %d", want, size)
+	}
+	used, err := d.UsedBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(512 << 20); want != used {
+		t.Errorf("want used %d, got %d", want, used)
+	}
+	avail, err := d.AvailableBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avail != 0 {
+		t.Errorf("want available 0, got %d", avail)
+	}
+	usage, err := d.UsagePercent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage != 29 {
+		t.Errorf("want usage 29, got %v", usage)
+	}
+}
+
+func TestDiskSpace_ErrorsForInvalidSize(t *testing.T) {
+	t.Parallel()
+	_, err := mikrus.DiskSpace{Size: "bogus"}.SizeBytes()
+	if err == nil {
+		t.Fatal("want error for invalid input, got nil")
+	}
+}
+
 func TestParseUptime_ParsesUptimeCommandOutput(t *testing.T) {
 	t.Parallel()
 	uptimeCmdOutput := "16:32:02 up 6 days,  8:33,  0 users,  load average: 0.10, 1.00, 0.50"
@@ -115,6 +158,7 @@ func TestParsePS_ParsesPSCommandOutput(t *testing.T) {
 		{
 			User:              "root",
 			PID:               21607,
+			PPID:              21605,
 			CPUPercent:        0.0,
 			MemoryPercent:     0.0,
 			VirtualMemorySize: 2608,
@@ -123,7 +167,7 @@ func TestParsePS_ParsesPSCommandOutput(t *testing.T) {
 			State:             "S",
 			Start:             "16:32",
 			CPUTime:           "0:00",
-			Command:           "\\_ sh",
+			Command:           "sh",
 		},
 	}
 	got, err := mikrus.ParsePS(psCmdOutput)
@@ -135,6 +179,190 @@ func TestParsePS_ParsesPSCommandOutput(t *testing.T) {
 	}
 }
 
+func TestParsePS_ParsesStatsOutputWithLeadingNoise(t *testing.T) {
+	t.Parallel()
+	var stats struct {
+		PS string `json:"ps"`
+	}
+	if err := json.Unmarshal([]byte(statResponse), &stats); err != nil {
+		t.Fatal(err)
+	}
+	got, err := mikrus.ParsePS(stats.PS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 17 {
+		t.Fatalf("want 17 processes, got %d", len(got))
+	}
+	wantNetworkd := mikrus.ProcessInfo{
+		User:              "systemd+",
+		PID:               73,
+		CPUPercent:        0.0,
+		MemoryPercent:     0.7,
+		VirtualMemorySize: 18376,
+		ResidentSetSize:   7616,
+		TTY:               "?",
+		State:             "Ss",
+		Start:             "Jun05",
+		CPUTime:           "0:00",
+		Command:           "/lib/systemd/systemd-networkd",
+	}
+	if !cmp.Equal(wantNetworkd, got[5]) {
+		t.Error(cmp.Diff(wantNetworkd, got[5]))
+	}
+	wantAgetty := mikrus.ProcessInfo{
+		User:              "root",
+		PID:               123,
+		CPUPercent:        0.0,
+		MemoryPercent:     0.2,
+		VirtualMemorySize: 8132,
+		ResidentSetSize:   2248,
+		TTY:               "pts/0",
+		State:             "Ss+",
+		Start:             "Jun05",
+		CPUTime:           "0:00",
+		Command:           "/sbin/agetty -o -p -- \\u --noclear --keep-baud tty1 115200,38400,9600 linux",
+	}
+	if !cmp.Equal(wantAgetty, got[14]) {
+		t.Error(cmp.Diff(wantAgetty, got[14]))
+	}
+	if got[16].PID != 126 {
+		t.Errorf("want last process PID 126, got %d", got[16].PID)
+	}
+}
+
+func TestParsePS_ParsesPSEFOutput(t *testing.T) {
+	t.Parallel()
+	psCmdOutput := "UID          PID    PPID  C STIME TTY          TIME CMD\nroot           1       0  0 Jun05 ?        00:00:04 /sbin/init\nwww-data    2100    2099  3 10:15 pts/0    00:01:02 php-fpm: pool www\n"
+	want := []mikrus.ProcessInfo{
+		{
+			User:       "root",
+			PID:        1,
+			PPID:       0,
+			CPUPercent: 0,
+			TTY:        "?",
+			Start:      "Jun05",
+			CPUTime:    "00:00:04",
+			Command:    "/sbin/init",
+		},
+		{
+			User:       "www-data",
+			PID:        2100,
+			PPID:       2099,
+			CPUPercent: 3,
+			TTY:        "pts/0",
+			Start:      "10:15",
+			CPUTime:    "00:01:02",
+			Command:    "php-fpm: pool www",
+		},
+	}
+	got, err := mikrus.ParsePS(psCmdOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestParsePS_ParsesCustomFormatOutput(t *testing.T) {
+	t.Parallel()
+	psCmdOutput := "  PID COMMAND                          STARTED %MEM\n    1 init            Mon Jun  5 09:00:00 2024  1.0\n   48 systemd-journal Mon Jun  5 09:00:01 2024  6.0"
+	want := []mikrus.ProcessInfo{
+		{PID: 1, Command: "init", Start: "Mon Jun  5 09:00:00 2024", MemoryPercent: 1.0},
+		{PID: 48, Command: "systemd-journal", Start: "Mon Jun  5 09:00:01 2024", MemoryPercent: 6.0},
+	}
+	got, err := mikrus.ParsePS(psCmdOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestParsePS_ParsesStartedValuesOfDifferentLengths(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		input string
+		want  []mikrus.ProcessInfo
+	}{
+		{
+			name:  "start before command",
+			input: "  PID  STARTED CMD\n    1 00:18:38 /sbin/init\n    2   Jun 05 kthreadd\n",
+			want: []mikrus.ProcessInfo{
+				{PID: 1, Start: "00:18:38", Command: "/sbin/init"},
+				{PID: 2, Start: "Jun 05", Command: "kthreadd"},
+			},
+		},
+		{
+			name:  "start after command",
+			input: "  PID CMD                          STARTED %MEM\n    1 /sbin/init splash          00:18:38  1.0\n    2 kthreadd                   Jun 05  0.0\n",
+			want: []mikrus.ProcessInfo{
+				{PID: 1, Command: "/sbin/init splash", Start: "00:18:38", MemoryPercent: 1.0},
+				{PID: 2, Command: "kthreadd", Start: "Jun 05"},
+			},
+		},
+		{
+			name:  "lstart and start mixed with other columns",
+			input: "  PID  STARTED %MEM CMD\n    1 Mon Jun  5 09:00:00 2024  1.0 /sbin/init\n    2 09:00:01  0.0 kthreadd\n    3   Jun 05  0.5 sshd: root@pts/0\n",
+			want: []mikrus.ProcessInfo{
+				{PID: 1, Start: "Mon Jun  5 09:00:00 2024", MemoryPercent: 1.0, Command: "/sbin/init"},
+				{PID: 2, Start: "09:00:01", Command: "kthreadd"},
+				{PID: 3, Start: "Jun 05", MemoryPercent: 0.5, Command: "sshd: root@pts/0"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		got, err := mikrus.ParsePS(tc.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !slices.Equal(tc.want, got) {
+			t.Errorf("%s: %s", tc.name, cmp.Diff(tc.want, got))
+		}
+	}
+}
+
+func TestNewProcessTree_BuildsTreeFromForestOutput(t *testing.T) {
+	t.Parallel()
+	psCmdOutput := "USER       PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND\n" +
+		"root       126  0.0  0.6  12172  7124 ?        Ss   Jun05   0:03 sshd: /usr/sbin/sshd -D\n" +
+		"root       200  0.0  0.6  12172  7124 ?        Ss   Jun05   0:00  \\_ sshd: root@pts/0\n" +
+		"root       201  0.0  0.6  12172  7124 pts/0    Ss   Jun05   0:00  |   \\_ -bash\n" +
+		"root       202  0.0  0.6  12172  7124 pts/0    R+   Jun05   0:00  |       \\_ ps auxf\n" +
+		"root       300  0.0  0.6  12172  7124 ?        Ss   Jun05   0:00  \\_ sshd: root@pts/1\n" +
+		"root         1  0.0  1.0 169412 10748 ?        Ss   Jun05   0:04 /sbin/init\n"
+	ps, err := mikrus.ParsePS(psCmdOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	mikrus.NewProcessTree(ps).Walk(func(p *mikrus.ProcessNode, depth int) {
+		got = append(got, fmt.Sprintf("%d %d %d %s", depth, p.PID, p.PPID, p.Command))
+	})
+	want := []string{
+		"0 126 0 sshd: /usr/sbin/sshd -D",
+		"1 200 126 sshd: root@pts/0",
+		"2 201 200 -bash",
+		"3 202 201 ps auxf",
+		"1 300 126 sshd: root@pts/1",
+		"0 1 0 /sbin/init",
+	}
+	if !slices.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestParsePS_ErrorsForMissingColumns(t *testing.T) {
+	t.Parallel()
+	_, err := mikrus.ParsePS("USER PID %CPU\nroot 1\n")
+	if err == nil {
+		t.Fatal("want error for invalid input, got nil")
+	}
+}
+
 func TestParsePS_ErrorsForInvalidInput(t *testing.T) {
 	t.Parallel()
 	_, err := mikrus.ParsePS("bogus")
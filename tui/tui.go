@@ -0,0 +1,426 @@
+// Package tui implements a full-screen terminal UI for managing
+// Mikrus servers.
+//
+// Servers are listed on the left. Details, live stats and recent logs
+// of the selected server are shown on the right and refreshed in the
+// background. Restart, boost and exec ask for confirmation first.
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/qba73/mikrus"
+)
+
+// Lister lists servers of the account.
+// *mikrus.Client implements Lister.
+type Lister interface {
+	Servers() (mikrus.Servers, error)
+}
+
+// Server is the API of a single server.
+// *mikrus.Client implements Server.
+type Server interface {
+	Info() (mikrus.Server, error)
+	Stats() (mikrus.Stats, error)
+	Logs() (mikrus.Logs, error)
+	Restart() (mikrus.TaskResponse, error)
+	Boost() (mikrus.TaskResponse, error)
+	Exec(command string) (string, error)
+}
+
+// Connector returns the API of the server with the ID. It returns
+// false if the server cannot be managed, for example when no API key
+// is configured for it.
+type Connector func(serverID string) (Server, bool)
+
+// Config configures the terminal UI.
+type Config struct {
+	Servers Lister
+	Connect Connector
+
+	// Interval is how often the server list and the selected server
+	// are refreshed. Zero disables background refresh.
+	Interval time.Duration
+}
+
+type mode int
+
+const (
+	browsing mode = iota
+	confirming
+	prompting
+	viewingLogs
+	viewingOutput
+)
+
+// details holds the last known state of a server.
+type details struct {
+	info    *mikrus.Server
+	stats   *mikrus.Stats
+	logs    mikrus.Logs
+	err     error
+	loading int
+	failed  bool
+	updated time.Time
+}
+
+// action is a task on a server awaiting confirmation.
+type action struct {
+	name     string
+	serverID string
+	command  string
+}
+
+// Model is the bubbletea model of the terminal UI.
+type Model struct {
+	cfg Config
+
+	servers  mikrus.Servers
+	listErr  error
+	selected int
+	details  map[string]*details
+
+	mode      mode
+	pending   action
+	input     string
+	logCursor int
+	output    string
+	status    string
+
+	width, height int
+}
+
+// New returns the model of the terminal UI.
+func New(cfg Config) Model {
+	return Model{
+		cfg:     cfg,
+		details: map[string]*details{},
+		width:   100,
+		height:  30,
+	}
+}
+
+// Run runs the terminal UI until the user quits.
+func Run(cfg Config) error {
+	_, err := tea.NewProgram(New(cfg), tea.WithAltScreen()).Run()
+	return err
+}
+
+type (
+	serversMsg struct {
+		servers mikrus.Servers
+		err     error
+	}
+	infoMsg struct {
+		serverID string
+		info     mikrus.Server
+		err      error
+	}
+	statsMsg struct {
+		serverID string
+		stats    mikrus.Stats
+		err      error
+	}
+	logsMsg struct {
+		serverID string
+		logs     mikrus.Logs
+		err      error
+	}
+	actionMsg struct {
+		action
+		res    mikrus.TaskResponse
+		output string
+		err    error
+	}
+	tickMsg time.Time
+)
+
+// Init implements tea.Model.
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.fetchServers(), m.tick())
+}
+
+func (m Model) tick() tea.Cmd {
+	if m.cfg.Interval <= 0 {
+		return nil
+	}
+	return tea.Tick(m.cfg.Interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m Model) fetchServers() tea.Cmd {
+	return func() tea.Msg {
+		servers, err := m.cfg.Servers.Servers()
+		return serversMsg{servers: servers, err: err}
+	}
+}
+
+// refresh fetches info, stats and logs of the server concurrently,
+// unless a refresh is already in progress.
+func (m Model) refresh(id string) tea.Cmd {
+	srv, ok := m.cfg.Connect(id)
+	if !ok {
+		return nil
+	}
+	d := m.detailsOf(id)
+	if d.loading > 0 {
+		return nil
+	}
+	d.loading = 3
+	return tea.Batch(
+		func() tea.Msg {
+			info, err := srv.Info()
+			return infoMsg{serverID: id, info: info, err: err}
+		},
+		func() tea.Msg {
+			stats, err := srv.Stats()
+			return statsMsg{serverID: id, stats: stats, err: err}
+		},
+		func() tea.Msg {
+			logs, err := srv.Logs()
+			return logsMsg{serverID: id, logs: logs, err: err}
+		},
+	)
+}
+
+func (m Model) detailsOf(id string) *details {
+	d, ok := m.details[id]
+	if !ok {
+		d = &details{}
+		m.details[id] = d
+	}
+	return d
+}
+
+// selectedID returns the ID of the selected server, or "" when
+// there are no servers.
+func (m Model) selectedID() string {
+	if m.selected < 0 || m.selected >= len(m.servers) {
+		return ""
+	}
+	return m.servers[m.selected].ServerID
+}
+
+// Update implements tea.Model.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tickMsg:
+		return m, tea.Batch(m.fetchServers(), m.refresh(m.selectedID()), m.tick())
+	case serversMsg:
+		m.listErr = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+		current := m.selectedID()
+		m.servers = msg.servers
+		m.selected = 0
+		for i, s := range m.servers {
+			if s.ServerID == current {
+				m.selected = i
+			}
+		}
+		if id := m.selectedID(); id != current {
+			return m, m.refresh(id)
+		}
+	case infoMsg:
+		d := m.loaded(msg.serverID, msg.err)
+		if msg.err == nil {
+			d.info = &msg.info
+		}
+	case statsMsg:
+		d := m.loaded(msg.serverID, msg.err)
+		if msg.err == nil {
+			d.stats = &msg.stats
+		}
+	case logsMsg:
+		d := m.loaded(msg.serverID, msg.err)
+		if msg.err == nil {
+			d.logs = msg.logs
+		}
+		if msg.serverID == m.selectedID() {
+			m.logCursor = clampIndex(m.logCursor, len(d.logs))
+		}
+	case actionMsg:
+		return m.actionDone(msg)
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// loaded records a finished request for the server details. The error
+// of a previous refresh is cleared once a refresh completes without
+// errors.
+func (m Model) loaded(id string, err error) *details {
+	d := m.detailsOf(id)
+	d.loading = max(d.loading-1, 0)
+	if err != nil {
+		d.err = err
+		d.failed = true
+	}
+	if d.loading == 0 {
+		if !d.failed {
+			d.err = nil
+			d.updated = time.Now()
+		}
+		d.failed = false
+	}
+	return d
+}
+
+// clampIndex returns i limited to indexes of a list of length n,
+// or 0 when the list is empty.
+func clampIndex(i, n int) int {
+	return max(min(i, n-1), 0)
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+	switch m.mode {
+	case confirming:
+		switch msg.String() {
+		case "y", "enter":
+			m.mode = browsing
+			m.status = fmt.Sprintf("Running %s on %s...", m.pending.name, m.pending.serverID)
+			return m, m.run(m.pending)
+		case "n", "esc", "q":
+			m.mode = browsing
+			m.status = "Cancelled."
+		}
+	case prompting:
+		switch msg.Type {
+		case tea.KeyEnter:
+			if m.input == "" {
+				return m, nil
+			}
+			m.pending = action{name: "exec", serverID: m.selectedID(), command: m.input}
+			m.mode = confirming
+		case tea.KeyEsc:
+			m.mode = browsing
+		case tea.KeyBackspace:
+			if r := []rune(m.input); len(r) > 0 {
+				m.input = string(r[:len(r)-1])
+			}
+		case tea.KeySpace:
+			m.input += " "
+		case tea.KeyRunes:
+			m.input += string(msg.Runes)
+		}
+	case viewingLogs:
+		logs := m.detailsOf(m.selectedID()).logs
+		switch msg.String() {
+		case "up", "k":
+			m.logCursor = clampIndex(m.logCursor-1, len(logs))
+		case "down", "j":
+			m.logCursor = clampIndex(m.logCursor+1, len(logs))
+		case "esc", "q", "l":
+			m.mode = browsing
+		}
+	case viewingOutput:
+		switch msg.String() {
+		case "esc", "q", "enter":
+			m.mode = browsing
+		}
+	default:
+		return m.browse(msg)
+	}
+	return m, nil
+}
+
+func (m Model) browse(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	id := m.selectedID()
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		return m.selectServer(m.selected - 1)
+	case "down", "j":
+		return m.selectServer(m.selected + 1)
+	case "R", "ctrl+r":
+		return m, tea.Batch(m.fetchServers(), m.refresh(id))
+	}
+	if id == "" {
+		return m, nil
+	}
+	if _, ok := m.cfg.Connect(id); !ok {
+		switch msg.String() {
+		case "r", "b", "e", "l", "enter":
+			m.status = fmt.Sprintf("Server %s is not configured, add a profile with its API key.", id)
+		}
+		return m, nil
+	}
+	switch msg.String() {
+	case "r":
+		m.pending = action{name: "restart", serverID: id}
+		m.mode = confirming
+	case "b":
+		m.pending = action{name: "boost", serverID: id}
+		m.mode = confirming
+	case "e":
+		m.input = ""
+		m.mode = prompting
+	case "l", "enter":
+		if len(m.detailsOf(id).logs) == 0 {
+			m.status = "No logs."
+			return m, nil
+		}
+		m.logCursor = 0
+		m.mode = viewingLogs
+	}
+	return m, nil
+}
+
+func (m Model) selectServer(i int) (tea.Model, tea.Cmd) {
+	if i < 0 || i >= len(m.servers) || i == m.selected {
+		return m, nil
+	}
+	m.selected = i
+	m.status = ""
+	id := m.selectedID()
+	if _, ok := m.details[id]; ok {
+		return m, nil
+	}
+	return m, m.refresh(id)
+}
+
+// run runs the confirmed action in the background.
+func (m Model) run(a action) tea.Cmd {
+	srv, ok := m.cfg.Connect(a.serverID)
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		msg := actionMsg{action: a}
+		switch a.name {
+		case "restart":
+			msg.res, msg.err = srv.Restart()
+		case "boost":
+			msg.res, msg.err = srv.Boost()
+		case "exec":
+			msg.output, msg.err = srv.Exec(a.command)
+		}
+		return msg
+	}
+}
+
+func (m Model) actionDone(msg actionMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.status = fmt.Sprintf("%s on %s failed: %v", msg.name, msg.serverID, msg.err)
+		return m, nil
+	}
+	switch msg.name {
+	case "exec":
+		m.output = fmt.Sprintf("$ %s\n\n%s", msg.command, msg.output)
+		m.mode = viewingOutput
+		m.status = ""
+	default:
+		m.status = fmt.Sprintf("%s on %s scheduled: %s", msg.name, msg.serverID, msg.res.Message)
+	}
+	return m, m.refresh(msg.serverID)
+}
@@ -0,0 +1,182 @@
+package tui_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/qba73/mikrus/mikrustest"
+	"github.com/qba73/mikrus/tui"
+)
+
+// newModel returns the model of the UI managing server j230 of
+// a mock API with servers j230 and a101, and the mock.
+func newModel(t *testing.T) (tea.Model, *mikrustest.Server) {
+	t.Helper()
+	return newScenarioModel(t, mikrustest.DefaultScenario())
+}
+
+// newScenarioModel is like newModel, but the mock API plays sc.
+func newScenarioModel(t *testing.T, sc mikrustest.Scenario) (tea.Model, *mikrustest.Server) {
+	t.Helper()
+	sc.Servers = append(sc.Servers, mikrustest.ServerConfig{ID: "a101", APIKey: "otherKey", Name: "backup", RAM: 2048, Disk: 20})
+	ts := mikrustest.NewServer(sc)
+	t.Cleanup(ts.Close)
+	c := ts.Client("j230")
+	m := tui.New(tui.Config{
+		Servers: &c,
+		Connect: func(id string) (tui.Server, bool) {
+			if id != "j230" {
+				return nil, false
+			}
+			return &c, true
+		},
+	})
+	return run(m, m.Init()), ts
+}
+
+// run runs the command and commands returned by updates, feeding
+// their messages to the model.
+func run(m tea.Model, cmd tea.Cmd) tea.Model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = run(m, c)
+		}
+	case tea.QuitMsg:
+	default:
+		var next tea.Cmd
+		m, next = m.Update(msg)
+		m = run(m, next)
+	}
+	return m
+}
+
+func press(m tea.Model, keys ...string) tea.Model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		m = run(m, cmd)
+	}
+	return m
+}
+
+func assertContains(t *testing.T, view string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(view, w) {
+			t.Errorf("want view containing %q, got:\n%s", w, view)
+		}
+	}
+}
+
+func TestModel_ShowsServersStatsAndLogsOfSelectedServer(t *testing.T) {
+	t.Parallel()
+	m, _ := newModel(t)
+	assertContains(t, m.View(),
+		"> j230", "a101 backup",
+		"Memory", "10% 102/1024 MB",
+		"Disk", "25% 2G/10G",
+		"3752 2024-06-05 10:05:34 sshkey",
+	)
+}
+
+func TestModel_ClearsErrorAfterSuccessfulRefresh(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Errors = []mikrustest.ErrorRule{{Verb: "stats", Status: http.StatusServiceUnavailable, Times: 1}}
+	m, _ := newScenarioModel(t, sc)
+	assertContains(t, m.View(), "Error: ")
+	m = press(m, "R")
+	if view := m.View(); strings.Contains(view, "Error: ") {
+		t.Errorf("want error cleared after refresh, got:\n%s", view)
+	}
+	assertContains(t, m.View(), "10% 102/1024 MB")
+}
+
+func TestModel_RestartsServerAfterConfirmation(t *testing.T) {
+	t.Parallel()
+	m, ts := newModel(t)
+
+	m = press(m, "r")
+	assertContains(t, m.View(), "Restart j230?")
+	m = press(m, "n")
+	if got := ts.Handler.Calls("restart"); got != 0 {
+		t.Fatalf("want no restart when cancelled, got %d calls", got)
+	}
+
+	m = press(m, "r", "y")
+	if got := ts.Handler.Calls("restart"); got != 1 {
+		t.Fatalf("want 1 restart, got %d", got)
+	}
+	assertContains(t, m.View(), "restart on j230 scheduled", "3753")
+}
+
+func TestModel_BoostsServerAfterConfirmation(t *testing.T) {
+	t.Parallel()
+	m, ts := newModel(t)
+	m = press(m, "b")
+	assertContains(t, m.View(), "Boost j230?")
+	press(m, "enter")
+	if got := ts.Handler.Calls("amfetamina"); got != 1 {
+		t.Fatalf("want 1 boost, got %d", got)
+	}
+}
+
+func TestModel_ExecutesCommandAndShowsOutput(t *testing.T) {
+	t.Parallel()
+	m, ts := newModel(t)
+	m = press(m, "e", "e", "c", "h", "o", " ", "hi", "enter")
+	assertContains(t, m.View(), `Run "echo hi" on j230?`)
+	m = press(m, "y")
+	if got := ts.Handler.Calls("exec"); got != 1 {
+		t.Fatalf("want 1 exec, got %d", got)
+	}
+	assertContains(t, m.View(), "$ echo hi", "hi")
+}
+
+func TestModel_ShowsLogDetails(t *testing.T) {
+	t.Parallel()
+	m, _ := newModel(t)
+	m = press(m, "l", "down")
+	assertContains(t, m.View(), "Task restart", "Done: 2024-06-05 09:58:07", "Result: succeeded: Server restarted")
+}
+
+func TestModel_KeepsLogCursorWithinLogs(t *testing.T) {
+	t.Parallel()
+	m, ts := newModel(t)
+	logs := ts.Handler.Logs("j230")
+	m = press(m, "l", "k")
+	assertContains(t, m.View(), "> "+logs[0].ID)
+	m = press(m, strings.Split(strings.Repeat("j", len(logs)+3), "")...)
+	assertContains(t, m.View(), "> "+logs[len(logs)-1].ID)
+}
+
+func TestModel_RefusesActionsOnServersWithoutAPIKey(t *testing.T) {
+	t.Parallel()
+	m, ts := newModel(t)
+	m = press(m, "down")
+	assertContains(t, m.View(), "> a101 backup", "Not configured")
+	m = press(m, "b")
+	assertContains(t, m.View(), "Server a101 is not configured")
+	if got := ts.Handler.Calls("amfetamina"); got != 0 {
+		t.Errorf("want no boost, got %d calls", got)
+	}
+}
@@ -0,0 +1,225 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/qba73/mikrus"
+)
+
+const listWidth = 26
+
+var (
+	panelStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	dialogStyle   = lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).Padding(1, 2)
+)
+
+// View implements tea.Model.
+func (m Model) View() string {
+	switch m.mode {
+	case confirming:
+		return m.dialog(m.question() + "\n\n[y] yes  [n] no")
+	case prompting:
+		return m.dialog(fmt.Sprintf("Run a command on %s:\n\n> %s_\n\n[enter] run  [esc] cancel", m.selectedID(), m.input))
+	case viewingLogs:
+		return m.screen(m.logView(), "[↑/↓] select  [esc] back")
+	case viewingOutput:
+		return m.screen(m.output, "[esc] back")
+	}
+	bodyHeight := m.bodyHeight()
+	list := panelStyle.Width(listWidth).Height(bodyHeight).Render(m.serverList())
+	// Panel widths include padding, but not borders.
+	detailsWidth := max(m.width-listWidth-4, 24)
+	right := panelStyle.Width(detailsWidth).Height(bodyHeight).Render(m.detailsView(detailsWidth - 2))
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, list, right),
+		m.footer("[↑/↓] select  [r] restart  [b] boost  [e] exec  [l] logs  [R] refresh  [q] quit"),
+	)
+}
+
+// bodyHeight returns the height of panels, leaving room for borders
+// and the footer.
+func (m Model) bodyHeight() int {
+	return max(m.height-4, 5)
+}
+
+func (m Model) screen(content, help string) string {
+	body := panelStyle.Width(max(m.width-2, 20)).Height(m.bodyHeight()).MaxHeight(m.bodyHeight() + 2).Render(content)
+	return lipgloss.JoinVertical(lipgloss.Left, body, m.footer(help))
+}
+
+func (m Model) dialog(content string) string {
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, dialogStyle.Render(content))
+}
+
+func (m Model) footer(help string) string {
+	line := dimStyle.Render(help)
+	if m.status != "" {
+		line = m.status + "  " + line
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+}
+
+func (m Model) question() string {
+	switch m.pending.name {
+	case "restart":
+		return fmt.Sprintf("Restart %s?", m.pending.serverID)
+	case "boost":
+		return fmt.Sprintf("Boost %s? It temporarily adds resources to the server.", m.pending.serverID)
+	default:
+		return fmt.Sprintf("Run %q on %s?", m.pending.command, m.pending.serverID)
+	}
+}
+
+func (m Model) serverList() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Servers") + "\n\n")
+	if m.listErr != nil {
+		b.WriteString(errorStyle.Render(m.listErr.Error()) + "\n\n")
+	}
+	for i, s := range m.servers {
+		line := s.ServerID
+		if s.ServerName != "" {
+			line += " " + s.ServerName
+		}
+		line = truncate(line, listWidth-4)
+		if i == m.selected {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+			continue
+		}
+		if _, ok := m.cfg.Connect(s.ServerID); !ok {
+			line = dimStyle.Render(line)
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	return b.String()
+}
+
+func (m Model) detailsView(width int) string {
+	if m.selected >= len(m.servers) {
+		return dimStyle.Render("Loading servers...")
+	}
+	s := m.servers[m.selected]
+	var b strings.Builder
+	title := s.ServerID
+	if s.ServerName != "" {
+		title += " (" + s.ServerName + ")"
+	}
+	b.WriteString(titleStyle.Render(title) + "\n")
+	fmt.Fprintf(&b, "Expires: %s  RAM: %s MB  Disk: %s GB\n", s.Expires, s.ParamRam, s.ParamDisk)
+
+	if _, ok := m.cfg.Connect(s.ServerID); !ok {
+		b.WriteString("\n" + dimStyle.Render("Not configured. Add a profile with the API key of this server to see its stats and logs."))
+		return b.String()
+	}
+	d, ok := m.details[s.ServerID]
+	if !ok {
+		b.WriteString("\n" + dimStyle.Render("Loading..."))
+		return b.String()
+	}
+	if d.info != nil {
+		pro := "no"
+		if d.info.Pro() {
+			pro = "yes"
+		}
+		fmt.Fprintf(&b, "Pro: %s  Last log: %s\n", pro, d.info.LastLogPanel)
+	}
+	if d.err != nil {
+		b.WriteString(errorStyle.Render(truncate("Error: "+d.err.Error(), width)) + "\n")
+	}
+
+	b.WriteString("\n" + titleStyle.Render("Stats") + "\n")
+	if d.stats == nil {
+		b.WriteString(dimStyle.Render("Loading...") + "\n")
+	} else {
+		b.WriteString(statsView(*d.stats, width))
+	}
+
+	b.WriteString("\n" + titleStyle.Render("Recent logs") + "\n")
+	for i, l := range d.logs {
+		if i == 5 {
+			break
+		}
+		line := fmt.Sprintf("%s %s %-8s %s", l.ID, l.WhenCreated, taskName(l.Task), l.Result())
+		b.WriteString(truncate(line, width) + "\n")
+	}
+	if !d.updated.IsZero() {
+		b.WriteString("\n" + dimStyle.Render("Updated "+d.updated.Format(time.TimeOnly)))
+	}
+	return b.String()
+}
+
+func statsView(s mikrus.Stats, width int) string {
+	barWidth := max(min(width-36, 40), 10)
+	var b strings.Builder
+	mem := s.Memory
+	fmt.Fprintf(&b, "Memory %s %3.0f%% %d/%d MB\n", gauge(barWidth, ratio(mem.Used, mem.Total)), 100*ratio(mem.Used, mem.Total), mem.Used, mem.Total)
+	if mem.SwapTotal > 0 {
+		fmt.Fprintf(&b, "Swap   %s %3.0f%% %d/%d MB\n", gauge(barWidth, ratio(mem.SwapUsed, mem.SwapTotal)), 100*ratio(mem.SwapUsed, mem.SwapTotal), mem.SwapUsed, mem.SwapTotal)
+	}
+	if usage, err := s.DiskSpace.UsagePercent(); err == nil {
+		fmt.Fprintf(&b, "Disk   %s %3.0f%% %s/%s\n", gauge(barWidth, usage/100), usage, s.DiskSpace.Used, s.DiskSpace.Size)
+	}
+	up := s.Uptime
+	fmt.Fprintf(&b, "Load   %.2f %.2f %.2f  Up %s  Processes %d\n", up.CPUload1min, up.CPUload5min, up.CPUload15min, up.Uptime, len(s.Processes))
+	return b.String()
+}
+
+func ratio(used, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(used) / float64(total)
+}
+
+// gauge returns a horizontal bar filled in the ratio.
+func gauge(width int, ratio float64) string {
+	filled := int(ratio*float64(width) + 0.5)
+	filled = max(min(filled, width), 0)
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+func (m Model) logView() string {
+	logs := m.detailsOf(m.selectedID()).logs
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Logs of "+m.selectedID()) + "\n\n")
+	for i, l := range logs {
+		line := fmt.Sprintf("%s %s %s", l.ID, l.WhenCreated, taskName(l.Task))
+		if i == m.logCursor {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+			continue
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	if m.logCursor < len(logs) {
+		l := logs[m.logCursor]
+		done := l.WhenDone
+		if done == "" {
+			done = "pending"
+		}
+		fmt.Fprintf(&b, "\n%s\nDone: %s\nResult: %s\n\n%s", titleStyle.Render("Task "+taskName(l.Task)), done, l.Result(), strings.TrimRight(l.Output, "\n"))
+	}
+	return b.String()
+}
+
+// taskName returns the English name of the task.
+func taskName(task string) string {
+	return mikrus.Log{Task: task}.Result().Task
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if width <= 0 || len(r) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(r[:width-1]) + "…"
+}
@@ -0,0 +1,185 @@
+package watch
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qba73/mikrus"
+)
+
+// Rule describes a condition on a server metric that raises an alert.
+type Rule struct {
+	Name string `yaml:"name"`
+
+	// Expr is a comparison of a metric with a threshold,
+	// like "disk_percent > 85" or "expiry_days < 14".
+	Expr string `yaml:"expr"`
+
+	// For is how long the condition must hold before the alert fires.
+	For time.Duration `yaml:"for"`
+
+	// Severity is passed on to notifications, like "warning".
+	Severity string `yaml:"severity"`
+
+	// Servers limits the rule to the listed server IDs.
+	// Empty list means all servers.
+	Servers []string `yaml:"servers"`
+
+	metric    string
+	op        string
+	threshold float64
+}
+
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// parse validates the rule expression.
+func (r *Rule) parse() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule %q: name is required", r.Expr)
+	}
+	for _, op := range operators {
+		metric, value, ok := strings.Cut(r.Expr, op)
+		if !ok {
+			continue
+		}
+		metric = strings.TrimSpace(metric)
+		if !slices.Contains(MetricNames, metric) {
+			return fmt.Errorf("rule %q: unknown metric %q", r.Name, metric)
+		}
+		threshold, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+		if err != nil {
+			return fmt.Errorf("rule %q: invalid threshold %q", r.Name, value)
+		}
+		r.metric, r.op, r.threshold = metric, op, threshold
+		return nil
+	}
+	return fmt.Errorf("rule %q: invalid expression %q, want: metric operator threshold", r.Name, r.Expr)
+}
+
+// matches reports whether the metric value satisfies the rule condition.
+func (r Rule) matches(value float64) bool {
+	switch r.op {
+	case ">":
+		return value > r.threshold
+	case ">=":
+		return value >= r.threshold
+	case "<":
+		return value < r.threshold
+	case "<=":
+		return value <= r.threshold
+	case "==":
+		return value == r.threshold
+	default:
+		return value != r.threshold
+	}
+}
+
+func (r Rule) appliesTo(serverID string) bool {
+	return len(r.Servers) == 0 || slices.Contains(r.Servers, serverID)
+}
+
+// State is the state of an alert.
+type State string
+
+const (
+	Firing   State = "firing"
+	Resolved State = "resolved"
+)
+
+// Alert represents a change of the rule state on a server.
+type Alert struct {
+	Rule     Rule
+	ServerID string
+	State    State
+	Value    float64
+
+	// Since is when the condition started to hold.
+	Since time.Time
+
+	// Time is when the alert changed state.
+	Time time.Time
+}
+
+// Sample holds metric values of a server collected at a point in time.
+type Sample struct {
+	ServerID string
+	Time     time.Time
+	Values   map[string]float64
+
+	// Server, Stats and Logs hold the data values were computed
+	// from. They are nil when the API calls failed.
+	Server *mikrus.Server
+	Stats  *mikrus.Stats
+	Logs   mikrus.Logs
+}
+
+type alertKey struct {
+	rule, server string
+}
+
+type alertState struct {
+	since  time.Time
+	firing bool
+}
+
+// Evaluator evaluates rules against samples and tracks alert states,
+// so every alert is reported once when it fires and once when
+// it is resolved.
+type Evaluator struct {
+	rules  []Rule
+	alerts map[alertKey]*alertState
+}
+
+// NewEvaluator validates the rules and returns an evaluator for them.
+func NewEvaluator(rules []Rule) (*Evaluator, error) {
+	names := map[string]bool{}
+	parsed := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		if err := r.parse(); err != nil {
+			return nil, err
+		}
+		if names[r.Name] {
+			return nil, fmt.Errorf("duplicate rule %q", r.Name)
+		}
+		names[r.Name] = true
+		parsed = append(parsed, r)
+	}
+	return &Evaluator{rules: parsed, alerts: map[alertKey]*alertState{}}, nil
+}
+
+// Evaluate evaluates all rules against the sample and returns alerts
+// that started firing or got resolved. Rules on metrics missing from
+// the sample keep their current state.
+func (e *Evaluator) Evaluate(s Sample) []Alert {
+	var alerts []Alert
+	for _, r := range e.rules {
+		if !r.appliesTo(s.ServerID) {
+			continue
+		}
+		value, ok := s.Values[r.metric]
+		if !ok {
+			continue
+		}
+		key := alertKey{rule: r.Name, server: s.ServerID}
+		st, tracked := e.alerts[key]
+		if !r.matches(value) {
+			if tracked && st.firing {
+				alerts = append(alerts, Alert{Rule: r, ServerID: s.ServerID, State: Resolved, Value: value, Since: st.since, Time: s.Time})
+			}
+			delete(e.alerts, key)
+			continue
+		}
+		if !tracked {
+			st = &alertState{since: s.Time}
+			e.alerts[key] = st
+		}
+		if !st.firing && s.Time.Sub(st.since) >= r.For {
+			st.firing = true
+			alerts = append(alerts, Alert{Rule: r, ServerID: s.ServerID, State: Firing, Value: value, Since: st.since, Time: s.Time})
+		}
+	}
+	return alerts
+}
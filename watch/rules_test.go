@@ -0,0 +1,94 @@
+package watch_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qba73/mikrus/watch"
+)
+
+var t0 = time.Date(2024, time.June, 5, 10, 0, 0, 0, time.UTC)
+
+func sample(at time.Duration, metric string, value float64) watch.Sample {
+	return watch.Sample{ServerID: "j230", Time: t0.Add(at), Values: map[string]float64{metric: value}}
+}
+
+func TestEvaluator_FiresAfterConditionHoldsForDuration(t *testing.T) {
+	t.Parallel()
+	e, err := watch.NewEvaluator([]watch.Rule{{Name: "disk-full", Expr: "disk_percent > 85%", For: 10 * time.Minute}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts := e.Evaluate(sample(0, "disk_percent", 90)); len(alerts) != 0 {
+		t.Fatalf("want no alerts before 10m, got %+v", alerts)
+	}
+	if alerts := e.Evaluate(sample(5*time.Minute, "disk_percent", 91)); len(alerts) != 0 {
+		t.Fatalf("want no alerts before 10m, got %+v", alerts)
+	}
+	alerts := e.Evaluate(sample(10*time.Minute, "disk_percent", 92))
+	if len(alerts) != 1 || alerts[0].State != watch.Firing || alerts[0].Value != 92 || !alerts[0].Since.Equal(t0) {
+		t.Fatalf("want firing alert since t0, got %+v", alerts)
+	}
+	if alerts := e.Evaluate(sample(15*time.Minute, "disk_percent", 93)); len(alerts) != 0 {
+		t.Fatalf("want firing alert reported once, got %+v", alerts)
+	}
+	alerts = e.Evaluate(sample(20*time.Minute, "disk_percent", 50))
+	if len(alerts) != 1 || alerts[0].State != watch.Resolved {
+		t.Fatalf("want resolved alert, got %+v", alerts)
+	}
+}
+
+func TestEvaluator_ResetsPendingAlert(t *testing.T) {
+	t.Parallel()
+	e, err := watch.NewEvaluator([]watch.Rule{{Name: "high-load", Expr: "load15 > 2", For: 10 * time.Minute}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Evaluate(sample(0, "load15", 3))
+	if alerts := e.Evaluate(sample(5*time.Minute, "load15", 1)); len(alerts) != 0 {
+		t.Fatalf("want no resolved alert for pending rule, got %+v", alerts)
+	}
+	if alerts := e.Evaluate(sample(10*time.Minute, "load15", 3)); len(alerts) != 0 {
+		t.Fatalf("want pending duration restarted, got %+v", alerts)
+	}
+}
+
+func TestEvaluator_KeepsStateForMissingMetrics(t *testing.T) {
+	t.Parallel()
+	e, err := watch.NewEvaluator([]watch.Rule{{Name: "swap", Expr: "swap > 0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts := e.Evaluate(sample(0, "swap", 12)); len(alerts) != 1 {
+		t.Fatalf("want firing alert, got %+v", alerts)
+	}
+	if alerts := e.Evaluate(sample(time.Minute, "up", 0)); len(alerts) != 0 {
+		t.Fatalf("want no change without swap metric, got %+v", alerts)
+	}
+}
+
+func TestEvaluator_AppliesRulesToListedServers(t *testing.T) {
+	t.Parallel()
+	e, err := watch.NewEvaluator([]watch.Rule{{Name: "expiring", Expr: "expiry_days < 14", Servers: []string{"a135"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alerts := e.Evaluate(sample(0, "expiry_days", 3)); len(alerts) != 0 {
+		t.Fatalf("want rule skipped for j230, got %+v", alerts)
+	}
+}
+
+func TestNewEvaluator_ErrorsForInvalidRules(t *testing.T) {
+	t.Parallel()
+	for _, rules := range [][]watch.Rule{
+		{{Name: "x", Expr: "bogus > 1"}},
+		{{Name: "x", Expr: "load1 ~ 1"}},
+		{{Name: "x", Expr: "load1 > high"}},
+		{{Expr: "load1 > 1"}},
+		{{Name: "x", Expr: "load1 > 1"}, {Name: "x", Expr: "load5 > 1"}},
+	} {
+		if _, err := watch.NewEvaluator(rules); err == nil {
+			t.Errorf("want error for %+v, got nil", rules)
+		}
+	}
+}
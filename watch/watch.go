@@ -0,0 +1,294 @@
+// Package watch polls Mikrus servers, evaluates alerting rules
+// against their metrics and delivers alerts and notifications about
+// finished tasks through notifiers.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/notify"
+	"go.yaml.in/yaml/v3"
+)
+
+// MetricNames lists metrics that can be used in rule expressions.
+//
+//   - up: 1 if the server responded to all API calls, 0 otherwise
+//   - memory_percent: used memory as percentage of total memory
+//   - memory_available: available memory in MB
+//   - swap: used swap in MB
+//   - disk_percent: used disk space as percentage of disk size
+//   - load1, load5, load15: load averages
+//   - processes: number of processes
+//   - zombies: number of zombie processes
+//   - expiry_days: days until the server expires
+//   - failed_logs: failed tasks among recent log entries added
+//     since the watch started
+var MetricNames = []string{
+	"up",
+	"memory_percent",
+	"memory_available",
+	"swap",
+	"disk_percent",
+	"load1",
+	"load5",
+	"load15",
+	"processes",
+	"zombies",
+	"expiry_days",
+	"failed_logs",
+}
+
+// Source provides information about a single Mikrus server.
+// *mikrus.Client implements Source.
+type Source interface {
+	Info() (mikrus.Server, error)
+	Stats() (mikrus.Stats, error)
+	Logs() (mikrus.Logs, error)
+}
+
+// Target represents a watched Mikrus server.
+type Target struct {
+	ServerID string
+	Source   Source
+}
+
+// Config describes rules and notifiers of the watch.
+type Config struct {
+	Interval  time.Duration   `yaml:"interval"`
+	Rules     []Rule          `yaml:"rules"`
+	Notifiers []notify.Config `yaml:"notifiers"`
+
+	// Tasks enables notifications about finished tasks.
+	Tasks bool `yaml:"tasks"`
+}
+
+// LoadConfig reads the watch config from a YAML file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing watch config %q: %w", path, err)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return cfg, nil
+}
+
+// Watcher polls targets and delivers alerts raised by the evaluator.
+type Watcher struct {
+	targets   []Target
+	evaluator *Evaluator
+	notifier  notify.Notifier
+
+	// ErrorLog specifies an optional logger for errors delivering
+	// alerts. If nil, errors are not logged.
+	ErrorLog *log.Logger
+
+	// NotifyTasks enables notifications about tasks that finish
+	// after the first poll of their server.
+	NotifyTasks bool
+
+	// logs tracks log entries of every server.
+	logs map[string]*logState
+}
+
+// logState holds IDs of log entries present when the watch started
+// and of finished tasks already announced.
+type logState struct {
+	baseline  map[string]bool
+	announced map[string]bool
+}
+
+// New creates a watcher for the targets.
+func New(targets []Target, rules []Rule, notifier notify.Notifier) (*Watcher, error) {
+	e, err := NewEvaluator(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		targets:   targets,
+		evaluator: e,
+		notifier:  notifier,
+		logs:      map[string]*logState{},
+	}, nil
+}
+
+// Run polls targets every interval until the context is cancelled.
+// Errors delivering alerts are logged and do not stop the watch.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := w.Poll(ctx); err != nil && w.ErrorLog != nil {
+			w.ErrorLog.Print(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll collects metrics of all targets once, evaluates rules and
+// delivers resulting alerts. It returns an error only if alerts
+// could not be delivered.
+func (w *Watcher) Poll(ctx context.Context) error {
+	var errs []error
+	for _, t := range w.targets {
+		sample := w.Collect(t)
+		var msgs []notify.Message
+		for _, a := range w.evaluator.Evaluate(sample) {
+			msgs = append(msgs, Message(a))
+		}
+		if w.NotifyTasks {
+			for _, l := range w.finishedTasks(t.ServerID, sample.Logs) {
+				m, err := TaskMessage(l, sample.Time)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				msgs = append(msgs, m)
+			}
+		}
+		for _, m := range msgs {
+			m.Server, m.Stats = sample.Server, sample.Stats
+			if err := w.notifier.Notify(ctx, m); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Collect returns current metric values of the target. Metrics
+// that could not be collected are missing from the sample.
+func (w *Watcher) Collect(t Target) Sample {
+	now := time.Now()
+	s := Sample{ServerID: t.ServerID, Time: now, Values: map[string]float64{"up": 1}}
+
+	if server, err := t.Source.Info(); err != nil {
+		s.Values["up"] = 0
+	} else {
+		s.Server = &server
+		if expires, err := server.ExpiresAt(); err == nil {
+			s.Values["expiry_days"] = expires.Sub(now).Hours() / 24
+		}
+	}
+
+	if stats, err := t.Source.Stats(); err != nil {
+		s.Values["up"] = 0
+	} else {
+		s.Stats = &stats
+		m := stats.Memory
+		if m.Total > 0 {
+			s.Values["memory_percent"] = 100 * float64(m.Used) / float64(m.Total)
+		}
+		s.Values["memory_available"] = float64(m.Available)
+		s.Values["swap"] = float64(m.SwapUsed)
+		if usage, err := stats.DiskSpace.UsagePercent(); err == nil {
+			s.Values["disk_percent"] = usage
+		}
+		s.Values["load1"] = stats.Uptime.CPUload1min
+		s.Values["load5"] = stats.Uptime.CPUload5min
+		s.Values["load15"] = stats.Uptime.CPUload15min
+		s.Values["processes"] = float64(len(stats.Processes))
+		s.Values["zombies"] = float64(len(stats.Processes.Zombies()))
+	}
+
+	if logs, err := t.Source.Logs(); err != nil {
+		s.Values["up"] = 0
+	} else {
+		s.Logs = logs
+		s.Values["failed_logs"] = float64(w.countFailedLogs(t.ServerID, logs))
+	}
+	return s
+}
+
+// countFailedLogs returns the number of failed entries among recent
+// log entries added after the first poll of the server. The count
+// drops once the entries are no longer returned by the API.
+func (w *Watcher) countFailedLogs(serverID string, logs mikrus.Logs) int {
+	st, ok := w.logs[serverID]
+	if !ok {
+		st = &logState{baseline: map[string]bool{}, announced: map[string]bool{}}
+		for _, l := range logs {
+			st.baseline[l.ID] = true
+			if l.WhenDone != "" {
+				st.announced[l.ID] = true
+			}
+		}
+		w.logs[serverID] = st
+		return 0
+	}
+	var failed int
+	for _, l := range logs {
+		if !st.baseline[l.ID] && logFailed(l) {
+			failed++
+		}
+	}
+	return failed
+}
+
+// finishedTasks returns log entries of tasks that finished since
+// the first poll of the server and were not returned before.
+func (w *Watcher) finishedTasks(serverID string, logs mikrus.Logs) []mikrus.Log {
+	st, ok := w.logs[serverID]
+	if !ok {
+		return nil
+	}
+	var finished []mikrus.Log
+	// The API lists the newest entries first.
+	for _, l := range slices.Backward(logs) {
+		if l.WhenDone == "" || st.announced[l.ID] {
+			continue
+		}
+		st.announced[l.ID] = true
+		finished = append(finished, l)
+	}
+	return finished
+}
+
+// logFailed reports whether the output of the finished task shows
+// it failed. Tasks with unrecognised output don't count as failed.
+func logFailed(l mikrus.Log) bool {
+	return l.Result().Failed
+}
+
+// Message returns a notification message for the alert.
+func Message(a Alert) notify.Message {
+	title := fmt.Sprintf("[%s] %s on %s", a.State, a.Rule.Name, a.ServerID)
+	text := fmt.Sprintf("%s (current value %.2f) since %s", a.Rule.Expr, a.Value, a.Since.Format(time.RFC3339))
+	if a.State == Resolved {
+		text = fmt.Sprintf("%s no longer holds (current value %.2f), was firing since %s", a.Rule.Expr, a.Value, a.Since.Format(time.RFC3339))
+	}
+	return notify.Message{
+		Title:    title,
+		Text:     text,
+		Severity: a.Rule.Severity,
+		ServerID: a.ServerID,
+		Time:     a.Time,
+	}
+}
+
+// TaskMessage returns a notification message about the finished task
+// rendered with the notify.TaskDone template. Failed tasks are
+// reported with the warning severity.
+func TaskMessage(l mikrus.Log, t time.Time) (notify.Message, error) {
+	m := notify.Message{Time: t}
+	if logFailed(l) {
+		m.Severity = "warning"
+	}
+	return notify.TaskDone.Render(notify.Data{Message: m, Log: &l})
+}
@@ -0,0 +1,168 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qba73/mikrus"
+	"github.com/qba73/mikrus/mikrustest"
+	"github.com/qba73/mikrus/notify"
+	"github.com/qba73/mikrus/watch"
+)
+
+func TestWatcher_NotifiesAboutFiringAndResolvedAlerts(t *testing.T) {
+	t.Parallel()
+	sc := mikrustest.DefaultScenario()
+	sc.Servers[0].Expires = time.Now().Add(7 * 24 * time.Hour).In(mikrus.Location).Format("2006-01-02 15:04:05")
+	ts := mikrustest.NewServer(sc)
+	defer ts.Close()
+
+	c := ts.Client("j230")
+	var out strings.Builder
+	w, err := watch.New(
+		[]watch.Target{{ServerID: "j230", Source: &c}},
+		[]watch.Rule{
+			{Name: "expiring", Expr: "expiry_days < 14"},
+			{Name: "disk-full", Expr: "disk_percent > 85"},
+			{Name: "failed-task", Expr: "failed_logs > 0"},
+		},
+		notify.Writer{W: &out},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if strings.Count(got, "[firing] expiring on j230") != 1 {
+		t.Errorf("want expiring alert reported once, got:\n%s", got)
+	}
+	if strings.Contains(got, "disk-full") {
+		t.Errorf("want no disk alert, got:\n%s", got)
+	}
+}
+
+type fakeSource struct {
+	logs mikrus.Logs
+}
+
+func (f *fakeSource) Info() (mikrus.Server, error) { return mikrus.Server{}, nil }
+
+func (f *fakeSource) Stats() (mikrus.Stats, error) { return mikrus.Stats{}, errors.New("boom") }
+
+func (f *fakeSource) Logs() (mikrus.Logs, error) { return f.logs, nil }
+
+func TestWatcher_AlertsOnNewFailedLogEntries(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{logs: mikrus.Logs{{ID: "1", Task: "upgrade", WhenDone: "2024-06-05 09:00:04", Output: "Błąd: brak środków"}}}
+	var out strings.Builder
+	w, err := watch.New(
+		[]watch.Target{{ServerID: "j230", Source: src}},
+		[]watch.Rule{{Name: "failed-task", Expr: "failed_logs > 0"}, {Name: "down", Expr: "up == 0"}},
+		notify.Writer{W: &out},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); strings.Contains(got, "failed-task") || !strings.Contains(got, "[firing] down on j230") {
+		t.Fatalf("want only down alert for failures present before the watch, got:\n%s", got)
+	}
+	out.Reset()
+	src.logs = append(mikrus.Logs{{ID: "2", Task: "restart", WhenDone: "2024-06-05 10:00:04", Output: "Restart failed\n"}}, src.logs...)
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "[firing] failed-task on j230") {
+		t.Fatalf("want failed task alert, got:\n%s", got)
+	}
+	out.Reset()
+	src.logs = mikrus.Logs{{ID: "3", Task: "restart", WhenDone: "2024-06-05 11:00:04", Output: "OK\n"}}
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.Contains(got, "[resolved] failed-task on j230") {
+		t.Fatalf("want resolved failed task alert, got:\n%s", got)
+	}
+}
+
+type recorder struct {
+	got []notify.Message
+}
+
+func (r *recorder) Notify(ctx context.Context, m notify.Message) error {
+	r.got = append(r.got, m)
+	return nil
+}
+
+func TestWatcher_NotifiesAboutFinishedTasks(t *testing.T) {
+	t.Parallel()
+	src := &fakeSource{logs: mikrus.Logs{
+		{ID: "2", ServerID: "j230", Task: "restart", WhenCreated: "2024-06-05 10:00:00"},
+		{ID: "1", ServerID: "j230", Task: "kluczssh", WhenCreated: "2024-06-05 09:00:00", WhenDone: "2024-06-05 09:00:04", Output: "Wrzuciłem klucz SSH\n"},
+	}}
+	r := &recorder{}
+	w, err := watch.New([]watch.Target{{ServerID: "j230", Source: src}}, nil, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.NotifyTasks = true
+	if err := w.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.got) != 0 {
+		t.Fatalf("want no notifications about tasks finished before the watch, got %v", r.got)
+	}
+	src.logs[0].WhenDone = "2024-06-05 10:00:12"
+	src.logs[0].Output = "Error: timeout\n"
+	src.logs = append(mikrus.Logs{{ID: "3", ServerID: "j230", Task: "kluczssh", WhenCreated: "2024-06-05 11:00:00", WhenDone: "2024-06-05 11:00:04", Output: "Wrzuciłem klucz SSH\n"}}, src.logs...)
+	for range 2 {
+		if err := w.Poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, m := range r.got {
+		got = append(got, m.Severity+" "+m.Title)
+		if m.Log == nil || m.Server == nil {
+			t.Errorf("want log entry and server info in message %q", m.Title)
+		}
+	}
+	want := []string{
+		"warning Task restart finished on j230",
+		" Task kluczssh finished on j230",
+	}
+	if !slices.Equal(want, got) {
+		t.Errorf("want task notifications %q, got %q", want, got)
+	}
+}
+
+func TestLoadConfig_ReadsRulesAndNotifiers(t *testing.T) {
+	t.Parallel()
+	cfg, err := watch.LoadConfig("testdata/watch.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Interval != 30*time.Second || !cfg.Tasks {
+		t.Errorf("want interval 30s and tasks enabled, got %v, %v", cfg.Interval, cfg.Tasks)
+	}
+	if len(cfg.Rules) != 5 || cfg.Rules[0].For != 10*time.Minute {
+		t.Errorf("want 5 rules, first for 10m, got %+v", cfg.Rules)
+	}
+	if _, err := watch.NewEvaluator(cfg.Rules); err != nil {
+		t.Error(err)
+	}
+	if len(cfg.Notifiers) != 3 || cfg.Notifiers[1].URL != "https://hooks.example.com/mikrus" || cfg.Notifiers[1].Retries != 2 {
+		t.Errorf("want stdout, webhook and slack notifiers, got %+v", cfg.Notifiers)
+	}
+}